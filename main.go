@@ -2,18 +2,105 @@ package main
 
 import (
 	"embed"
+	"flag"
+	"image"
+	"image/png"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
 	"wfc2/pkg/game"
+	"wfc2/pkg/server"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-//go:embed static/images/* static/rules/*
+//go:embed static/images/* static/rules/* static/samples/*
 var embededStatic embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "voxel" {
+		voxel(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "learn" {
+		learn(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "overlap" {
+		overlap(os.Args[2:])
+		return
+	}
+
+	seed := flag.Uint64("seed", 42, "seed for the random number generator")
+	tiledDir := flag.String("tiled", "", "export the board as a Tiled map into this directory and exit")
+	ldtkDir := flag.String("ldtk", "", "export the board as an LDtk project into this directory and exit")
+	svgFile := flag.String("svg", "", "write the board to this svg file and exit")
+	ascii := flag.Bool("ascii", false, "print the board to the terminal using box drawing characters and exit")
+	colour := flag.Bool("colour", false, "colour the terminal output with ansi colours, used with -ascii")
+	steps := flag.Int("steps", 0, "cards the viewer places each frame to animate the collapse, 0 generates instantly")
+	dir := flag.String("dir", "", "load the rules and images from this directory instead of the embedded ones, reloading them when they change")
+	flag.Parse()
+
+	var fsys fs.FS = embededStatic
+	if *dir != "" {
+		fsys = os.DirFS(*dir)
+	}
+
+	g := game.NewGame(fsys, *seed)
+	if *dir != "" {
+		g.RulesFile = filepath.Join(*dir, filepath.FromSlash(game.DefaultRulesFile))
+		g.WatchFiles()
+	}
+
+	// the exports generate quietly, so that nothing but the board is written to standard output
+	if *tiledDir != "" || *ldtkDir != "" || *ascii || *svgFile != "" {
+		g.Generate()
+	}
+
+	if *tiledDir != "" {
+		if err := g.ExportTiled(*tiledDir, "wfc"); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *ldtkDir != "" {
+		if err := g.ExportLDtk(*ldtkDir, "wfc"); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *ascii {
+		if err := g.WriteTerminal(os.Stdout, *colour); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *svgFile != "" {
+		f, err := os.Create(*svgFile)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		if err := g.WriteSVG(f); err != nil {
+			panic(err)
+		}
+		return
+	}
 
-	g := game.NewGame(embededStatic, 42)
 	g.CreateLandscape()
+	if *steps > 0 {
+		g.StepsPerFrame = *steps
+		g.Animate()
+	}
 
 	ebiten.SetWindowSize(720, 720)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
@@ -23,3 +110,141 @@ func main() {
 	}
 
 }
+
+// serve runs the http api for generating maps, rather than the viewer
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	flags.Parse(args)
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.New(embededStatic).Handler()))
+}
+
+// voxel generates a three dimensional board and writes the placed modules as json
+func voxel(args []string) {
+	flags := flag.NewFlagSet("voxel", flag.ExitOnError)
+	rulesFile := flags.String("rules", "static/rules/voxelRules.json", "rules with the voxel topology")
+	seed := flags.Uint64("seed", 42, "seed for the random number generator")
+	out := flags.String("out", "", "file to write the voxels to, standard output if empty")
+	flags.Parse(args)
+
+	rules := game.LoadRules(*rulesFile, embededStatic)
+	if err := rules.Validate(embededStatic); err != nil {
+		panic(err)
+	}
+	if rules.Topology != game.Voxel {
+		log.Fatalf("%s doesn't have the voxel topology", *rulesFile)
+	}
+	v := game.NewVoxels(rules, game.BuildCards(rules, embededStatic), *seed)
+	if !v.Generate() {
+		log.Printf("seed %d couldn't fill every cell", *seed)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := v.WriteJSON(w); err != nil {
+		panic(err)
+	}
+}
+
+// overlap generates an image from the patterns in a sample image using the overlapping model
+func overlap(args []string) {
+	flags := flag.NewFlagSet("overlap", flag.ExitOnError)
+	sampleFile := flags.String("sample", "", "png to learn the patterns from, the embedded static/samples/roads.png if empty")
+	n := flags.Int("n", 3, "size of the patterns")
+	symmetry := flags.Int("symmetry", 8, "rotations and reflections of each pattern to use, from 1 to 8")
+	periodic := flags.Bool("periodic", false, "treat the sample as wrapping around at its edges")
+	width := flags.Int("width", 48, "width of the generated image")
+	height := flags.Int("height", 48, "height of the generated image")
+	seed := flags.Uint64("seed", 42, "seed for the random number generator")
+	out := flags.String("out", "overlap.png", "png file to write the generated image to")
+	flags.Parse(args)
+
+	var f fs.File
+	var err error
+	if *sampleFile == "" {
+		f, err = embededStatic.Open("static/samples/roads.png")
+	} else {
+		f, err = os.Open(*sampleFile)
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	sample, _, err := image.Decode(f)
+	if err != nil {
+		panic(err)
+	}
+
+	o, err := game.LearnPatterns(sample, *n, *symmetry, *periodic)
+	if err != nil {
+		log.Fatal(err)
+	}
+	img, ok := o.Generate(*width, *height, *seed)
+	if !ok {
+		log.Fatalf("seed %d couldn't fill the image in %d attempts", *seed, game.OverlappingAttempts)
+	}
+
+	w, err := os.Create(*out)
+	if err != nil {
+		panic(err)
+	}
+	defer w.Close()
+	if err := png.Encode(w, img); err != nil {
+		panic(err)
+	}
+}
+
+// learn writes rules with the adjacency and chances of the cards in an example board
+func learn(args []string) {
+	flags := flag.NewFlagSet("learn", flag.ExitOnError)
+	rulesFile := flags.String("rules", game.DefaultRulesFile, "rules with the cards the example uses")
+	exampleFile := flags.String("example", "", "example board of card ids, json or a grid with a row on each line")
+	out := flags.String("out", "", "file to write the learned rules to, standard output if empty")
+	dir := flags.String("dir", "", "load the rules and images from this directory instead of the embedded ones")
+	flags.Parse(args)
+
+	var fsys fs.FS = embededStatic
+	if *dir != "" {
+		fsys = os.DirFS(*dir)
+	}
+
+	if *exampleFile == "" {
+		log.Fatal("learn needs an -example board")
+	}
+	data, err := os.ReadFile(*exampleFile)
+	if err != nil {
+		panic(err)
+	}
+	example, err := game.ParseExample(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rules := game.LoadRules(*rulesFile, fsys)
+	learned, err := game.LearnRules(rules, game.BuildCards(rules, fsys), example)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := game.WriteRules(w, learned); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,199 @@
+package game
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// emptyExampleCell is how a cell without a card is written in a character grid example
+const emptyExampleCell = "."
+
+// AdjacencyRule allows the Neighbour card next to Card on the Side, N, E, S or W
+// Count is how many times the pair was next to each other in the example the rule was learned from
+type AdjacencyRule struct {
+	Card      int    `json:"card"`
+	Side      string `json:"side"`
+	Neighbour int    `json:"neighbour"`
+	Count     int    `json:"count,omitempty"`
+}
+
+// adjacencyPair is a card with a neighbour on one of its sides
+type adjacencyPair struct {
+	card, side, neighbour int
+}
+
+// adjacency is the rules' adjacency as sets, pairs are the neighbours allowed and sides the sides of
+// each card that have any, it is nil when the rules don't have adjacency rules
+type adjacency struct {
+	pairs map[adjacencyPair]bool
+	sides map[[2]int]bool
+}
+
+func newAdjacency(rules []AdjacencyRule) *adjacency {
+	if len(rules) == 0 {
+		return nil
+	}
+	a := &adjacency{pairs: map[adjacencyPair]bool{}, sides: map[[2]int]bool{}}
+	for _, rule := range rules {
+		side := slices.Index(sideNames, rule.Side)
+		a.pairs[adjacencyPair{rule.Card, side, rule.Neighbour}] = true
+		a.sides[[2]int{rule.Card, side}] = true
+	}
+	return a
+}
+
+// prepareRules gets the game ready to use its rules, they should already be validated
+func (g *Game) prepareRules() {
+	g.loadMask()
+	g.adjacency = newAdjacency(g.Rules.Adjacency)
+}
+
+// adjacent reports whether the neighbour card can be on the side of the card, always true without adjacency rules
+func (g *Game) adjacent(card, side, neighbour int) bool {
+	return g.adjacency == nil || g.adjacency.pairs[adjacencyPair{card, side, neighbour}]
+}
+
+// allowedNeighbours keeps the ids of the cards that the adjacency rules allow at (i, j)
+// each placed neighbour must be allowed next to the card, and each empty neighbour must have at least one
+// card allowed next to it, so the card doesn't leave it with nothing that fits
+func (g *Game) allowedNeighbours(board [][]buildCell, i, j int, ids []int) []int {
+	if g.adjacency == nil {
+		return ids
+	}
+	rows, columns := len(board), len(board[0])
+	return slices.DeleteFunc(ids, func(id int) bool {
+		for side, offset := range sideOffsets {
+			ni, nj, ok := neighbour(rows, columns, i, j, offset, g.Rules.Wrap)
+			if !ok {
+				continue
+			}
+			cell := board[ni][nj]
+			switch {
+			case !cell.placed && !g.adjacency.sides[[2]int{id, side}]:
+				return true
+			case cell.placed && cell.id != 0 && !g.adjacent(id, side, cell.id):
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ParseExample reads an example board of card ids, either as json, an array of rows,
+// or as a character grid with a row on each line, ids separated by spaces and "." for an empty cell
+// empty cells are 0 in the returned board
+func ParseExample(data []byte) ([][]int, error) {
+	var example [][]int
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err := json.Unmarshal(trimmed, &example)
+		if err != nil {
+			return nil, fmt.Errorf("example: %w", err)
+		}
+	} else {
+		for n, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			row := make([]int, len(fields))
+			for k, field := range fields {
+				if field == emptyExampleCell {
+					continue
+				}
+				id, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("example line %d: %q isn't a card id", n+1, field)
+				}
+				row[k] = id
+			}
+			example = append(example, row)
+		}
+	}
+
+	if len(example) == 0 || len(example[0]) == 0 {
+		return nil, errors.New("example is empty")
+	}
+	for i, row := range example {
+		if len(row) != len(example[0]) {
+			return nil, fmt.Errorf("example row %d has %d cells, the first row has %d", i, len(row), len(example[0]))
+		}
+	}
+	return example, nil
+}
+
+// LearnRules returns a copy of the rules with adjacency rules for every pair of cards next to each other in the
+// example, and each base card's chance set from how often its cards appear, so the rules generate boards in the
+// same style as the example, it returns an error if the example has cards next to each other that don't connect
+func LearnRules(rules BasicRules, cards map[int]*Card, example [][]int) (BasicRules, error) {
+	if rules.Topology != Square {
+		return rules, fmt.Errorf("can't learn adjacency for %s cards", rules.Topology)
+	}
+
+	var errs []error
+	rows, columns := len(example), len(example[0])
+	counts := map[adjacencyPair]int{}
+	baseCounts := make([]int, len(rules.BaseCards))
+	total := 0
+	for i, row := range example {
+		for j, id := range row {
+			if id == 0 {
+				continue
+			}
+			card, ok := cards[id]
+			if !ok {
+				errs = append(errs, fmt.Errorf("(%d, %d) has unknown card id %d", i, j, id))
+				continue
+			}
+			baseCounts[card.Base]++
+			total++
+
+			for side, offset := range sideOffsets {
+				ni, nj, ok := neighbour(rows, columns, i, j, offset, WrapNone)
+				if !ok || example[ni][nj] == 0 {
+					continue
+				}
+				other, ok := cards[example[ni][nj]]
+				if !ok {
+					continue
+				}
+				if card.Connectors[side]&other.Connectors[(side+2)%4] == 0 {
+					errs = append(errs, fmt.Errorf("card %d at (%d, %d) doesn't connect to card %d on its %s side", id, i, j, other.Id, sideNames[side]))
+					continue
+				}
+				counts[adjacencyPair{id, side, other.Id}]++
+			}
+		}
+	}
+	if total == 0 {
+		errs = append(errs, errors.New("example has no cards"))
+	}
+	if len(errs) > 0 {
+		return rules, errors.Join(errs...)
+	}
+
+	learned := cloneRules(rules)
+	learned.Adjacency = make([]AdjacencyRule, 0, len(counts))
+	for pair, count := range counts {
+		learned.Adjacency = append(learned.Adjacency, AdjacencyRule{Card: pair.card, Side: sideNames[pair.side], Neighbour: pair.neighbour, Count: count})
+	}
+	slices.SortFunc(learned.Adjacency, func(a, b AdjacencyRule) int {
+		return cmp.Or(cmp.Compare(a.Card, b.Card), cmp.Compare(slices.Index(sideNames, a.Side), slices.Index(sideNames, b.Side)), cmp.Compare(a.Neighbour, b.Neighbour))
+	})
+
+	// each of a base card's rotations is picked with its chance, so the chance is shared between them
+	// base cards that don't appear get the lowest chance, the adjacency rules keep them off the board
+	learned.Randomiser = SimpleWeighted
+	for base := range learned.BaseCards {
+		variants := 1 + len(learned.BaseCards[base].Rotations)
+		chance := 100 * float64(baseCounts[base]) / float64(total*variants)
+		learned.BaseCards[base].Chance = max(1, int(math.Round(chance)))
+	}
+	return learned, nil
+}
@@ -0,0 +1,143 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roadExample is rows of grass with a road running east to west through the middle
+const roadExample = `
+1 1 1 1
+3 3 3 3
+1 1 1 1
+`
+
+func getLearnRules(t *testing.T) (BasicRules, map[int]*Card) {
+	t.Helper()
+	fs := getFS()
+	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules.BoardWidth, rules.BoardHeight = 6, 8
+	rules.SeedTiles = nil
+	return rules, BuildCards(rules, fs)
+}
+
+func Test_ParseExample(t *testing.T) {
+	grid, err := ParseExample([]byte("1 2 .\n4 5 6\n"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	json, err := ParseExample([]byte(" [[1, 2, 0], [4, 5, 6]]"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := [][]int{{1, 2, 0}, {4, 5, 6}}
+	if !reflect.DeepEqual(grid, want) || !reflect.DeepEqual(json, want) {
+		t.Errorf("got %v and %v, want %v", grid, json, want)
+	}
+
+	for _, bad := range []string{"", "1 2\n3\n", "1 x\n", "[[1, 2], [3]]"} {
+		if _, err := ParseExample([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func Test_LearnRules(t *testing.T) {
+	rules, cards := getLearnRules(t)
+	example, err := ParseExample([]byte(roadExample))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	learned, err := LearnRules(rules, cards, example)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	want := []AdjacencyRule{
+		{1, "N", 3, 4}, {1, "E", 1, 6}, {1, "S", 3, 4}, {1, "W", 1, 6},
+		{3, "N", 1, 4}, {3, "E", 3, 3}, {3, "S", 1, 4}, {3, "W", 3, 3},
+	}
+	if !reflect.DeepEqual(learned.Adjacency, want) {
+		t.Errorf("adjacency got %v, want %v", learned.Adjacency, want)
+	}
+	// 8 of the 12 cards are grass, and the 4 roads are shared between the straight's two cards
+	chances := []int{67, 17, 1, 1, 1}
+	for base, chance := range chances {
+		if learned.BaseCards[base].Chance != chance {
+			t.Errorf("base card %d chance got %d, want %d", base, learned.BaseCards[base].Chance, chance)
+		}
+	}
+	if learned.Randomiser != SimpleWeighted {
+		t.Errorf("randomiser got %d, want SimpleWeighted", learned.Randomiser)
+	}
+	if len(rules.Adjacency) != 0 || rules.BaseCards[0].Chance != 10 {
+		t.Errorf("learning changed the original rules")
+	}
+	if err := learned.Validate(getFS()); err != nil {
+		t.Errorf("learned rules aren't valid: %v", err)
+	}
+}
+
+func Test_LearnedRulesGenerate(t *testing.T) {
+	rules, cards := getLearnRules(t)
+	example, _ := ParseExample([]byte(roadExample))
+	learned, err := LearnRules(rules, cards, example)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	g := NewGameWithRules(getFS(), learned, BuildCards(learned, getFS()), 4)
+	if !g.Generate() {
+		t.Fatalf("board didn't complete, %d placed", g.Placed())
+	}
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if tile.Card == nil {
+				continue
+			}
+			if tile.Card.Id != 1 && tile.Card.Id != 3 {
+				t.Errorf("card %d at (%d, %d) isn't in the example", tile.Card.Id, i, j)
+			}
+			for side, offset := range sideOffsets {
+				ni, nj, ok := neighbour(6, 8, i, j, offset, WrapNone)
+				if !ok || g.Board[ni][nj].Card == nil {
+					continue
+				}
+				if other := g.Board[ni][nj].Card; !g.adjacent(tile.Card.Id, side, other.Id) {
+					t.Errorf("card %d at (%d, %d) has card %d on its %s side", tile.Card.Id, i, j, other.Id, sideNames[side])
+				}
+			}
+		}
+	}
+
+	// the inspector only lists the neighbours the rules allow
+	if got := fmt.Sprint(g.compatibleNeighbours(cards[1], 2)); got != "[3]" {
+		t.Errorf("compatible neighbours south of grass got %s, want [3]", got)
+	}
+}
+
+func Test_LearnRulesErrors(t *testing.T) {
+	rules, cards := getLearnRules(t)
+
+	// grass next to the crossroads
+	_, err := LearnRules(rules, cards, [][]int{{1, 4}})
+	if err == nil || !strings.Contains(err.Error(), "doesn't connect") {
+		t.Errorf("got error %v, want one about cards that don't connect", err)
+	}
+	_, err = LearnRules(rules, cards, [][]int{{1, 99}})
+	if err == nil || !strings.Contains(err.Error(), "unknown card id 99") {
+		t.Errorf("got error %v, want one about an unknown card", err)
+	}
+	_, err = LearnRules(rules, cards, [][]int{{0, 0}})
+	if err == nil || !strings.Contains(err.Error(), "no cards") {
+		t.Errorf("got error %v, want one about an empty example", err)
+	}
+
+	rules.Adjacency = []AdjacencyRule{{Card: 1, Side: "up", Neighbour: 13}}
+	err = rules.Validate(getFS())
+	if err == nil || !strings.Contains(err.Error(), "unknown card id") || !strings.Contains(err.Error(), `got "up"`) {
+		t.Errorf("got error %v, want errors for the card id and side", err)
+	}
+}
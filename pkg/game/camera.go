@@ -0,0 +1,169 @@
+package game
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// camera keys, as well as dragging with the middle mouse button and zooming with the wheel
+//
+//	arrows / wasd  pan
+//	= / -          zoom in and out
+//	home           fit the board to the window
+const (
+	keyZoomIn  = ebiten.KeyEqual
+	keyZoomOut = ebiten.KeyMinus
+	keyFit     = ebiten.KeyHome
+)
+
+const (
+	minZoom   = 0.05
+	maxZoom   = 8
+	panSpeed  = 8    // screen pixels per frame
+	zoomSpeed = 1.02 // per frame the zoom key is held
+	wheelZoom = 1.1  // per notch of the wheel
+)
+
+// camera is the top left of the screen in board pixels and how much the board is scaled by
+type camera struct {
+	x    float64
+	y    float64
+	zoom float64
+
+	dragging bool
+	dragX    int
+	dragY    int
+}
+
+func (c camera) scale() float64 {
+	if c.zoom == 0 {
+		return 1
+	}
+	return c.zoom
+}
+
+// geoM converts board pixels to screen pixels
+func (c camera) geoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Translate(-c.x, -c.y)
+	m.Scale(c.scale(), c.scale())
+	return m
+}
+
+// toBoard converts a screen position to board pixels
+func (c camera) toBoard(x, y int) (float64, float64) {
+	return float64(x)/c.scale() + c.x, float64(y)/c.scale() + c.y
+}
+
+// zoomAt changes the zoom keeping the board pixel under the screen position in the same place
+func (c *camera) zoomAt(factor float64, x, y int) {
+	bx, by := c.toBoard(x, y)
+	c.zoom = min(max(c.scale()*factor, minZoom), maxZoom)
+	c.x = bx - float64(x)/c.zoom
+	c.y = by - float64(y)/c.zoom
+}
+
+func (g *Game) updateCamera() {
+	mx, my := ebiten.CursorPosition()
+
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+		if g.cam.dragging {
+			g.cam.x -= float64(mx-g.cam.dragX) / g.cam.scale()
+			g.cam.y -= float64(my-g.cam.dragY) / g.cam.scale()
+		}
+		g.cam.dragging = true
+		g.cam.dragX, g.cam.dragY = mx, my
+	} else {
+		g.cam.dragging = false
+	}
+
+	if _, wheel := ebiten.Wheel(); wheel != 0 {
+		g.cam.zoomAt(math.Pow(wheelZoom, wheel), mx, my)
+	}
+
+	step := panSpeed / g.cam.scale()
+	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
+		g.cam.x -= step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
+		g.cam.x += step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
+		g.cam.y -= step
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
+		g.cam.y += step
+	}
+
+	if ebiten.IsKeyPressed(keyZoomIn) {
+		g.cam.zoomAt(zoomSpeed, g.screenWidth/2, g.screenHeight/2)
+	}
+	if ebiten.IsKeyPressed(keyZoomOut) {
+		g.cam.zoomAt(1/zoomSpeed, g.screenWidth/2, g.screenHeight/2)
+	}
+	if ebiten.IsKeyPressed(keyFit) {
+		g.fitCamera()
+	}
+}
+
+// fitCamera zooms so that the whole board, and its border, fits on the screen
+func (g *Game) fitCamera() {
+	width, height := g.boardSize()
+	width += 2 * boardOffset
+	height += 2 * boardOffset
+	g.cam.zoom = min(max(min(float64(g.screenWidth)/width, float64(g.screenHeight)/height), minZoom), maxZoom)
+	g.cam.x, g.cam.y = 0, 0
+}
+
+// boardSize returns the width and height of the board in board pixels, without the border
+func (g *Game) boardSize() (width, height float64) {
+	if g.Rules.Topology == Hex {
+		return hexBoardSize(g.Rules.BoardWidth, g.Rules.BoardHeight, tileSize)
+	}
+	return float64(g.Rules.BoardHeight * tileSize), float64(g.Rules.BoardWidth * tileSize)
+}
+
+// cellPosition returns the top left of the cell in board pixels
+// for hexes it is the top left of the tileSize square around the hex
+func (g *Game) cellPosition(i, j int) (x, y float64) {
+	if g.Rules.Topology == Hex {
+		cx, cy := hexCentre(i, j, tileSize)
+		return cx - tileSize/2 + boardOffset, cy - tileSize/2 + boardOffset
+	}
+	return squarePosition(i, j)
+}
+
+// squarePosition returns the top left of a square cell in board pixels
+func squarePosition(i, j int) (x, y float64) {
+	return float64(j*tileSize + boardOffset), float64(i*tileSize + boardOffset)
+}
+
+// screenRect returns the top left and size of the cell on the screen
+func (g *Game) screenRect(i, j int) (x, y, size float32) {
+	bx, by := g.cellPosition(i, j)
+	cam := g.cam.geoM()
+	sx, sy := cam.Apply(bx, by)
+	return float32(sx), float32(sy), float32(tileSize * g.cam.scale())
+}
+
+// onScreen returns true if any of the cell can be seen
+func (g *Game) onScreen(i, j int) bool {
+	x, y, size := g.screenRect(i, j)
+	return x+size >= 0 && y+size >= 0 && x <= float32(g.screenWidth) && y <= float32(g.screenHeight)
+}
+
+// cellAt returns the board cell under the screen position
+func (g *Game) cellAt(x, y int) (i, j int, ok bool) {
+	bx, by := g.cam.toBoard(x, y)
+	if g.Rules.Topology == Hex {
+		i, j = hexAt(bx-boardOffset, by-boardOffset, tileSize)
+	} else {
+		i = int(math.Floor((by - boardOffset) / tileSize))
+		j = int(math.Floor((bx - boardOffset) / tileSize))
+	}
+	if i < 0 || j < 0 || i >= len(g.Board) || j >= len(g.Board[i]) {
+		return 0, 0, false
+	}
+	return i, j, true
+}
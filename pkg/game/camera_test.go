@@ -0,0 +1,55 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_cameraZoomAt(t *testing.T) {
+	c := camera{x: 10, y: 20}
+
+	bx, by := c.toBoard(100, 50)
+	c.zoomAt(2, 100, 50)
+
+	if c.scale() != 2 {
+		t.Errorf("got zoom %f, want 2", c.scale())
+	}
+	gotX, gotY := c.toBoard(100, 50)
+	if math.Abs(gotX-bx) > 1e-9 || math.Abs(gotY-by) > 1e-9 {
+		t.Errorf("point under the cursor moved from (%f, %f) to (%f, %f)", bx, by, gotX, gotY)
+	}
+
+	c.zoomAt(1000, 0, 0)
+	if c.scale() != maxZoom {
+		t.Errorf("zoom not clamped, got %f", c.scale())
+	}
+}
+
+func Test_cellAt(t *testing.T) {
+	g := getTestGame()
+
+	tests := []struct {
+		name   string
+		cam    camera
+		x, y   int
+		i, j   int
+		wantOk bool
+	}{
+		{"top left cell", camera{}, boardOffset, boardOffset, 0, 0, true},
+		{"before the board", camera{}, boardOffset - 1, boardOffset, 0, 0, false},
+		{"after the board", camera{}, boardOffset + 3*tileSize, boardOffset, 0, 0, false},
+		{"row then column", camera{}, boardOffset + 2*tileSize, boardOffset + tileSize, 1, 2, true},
+		{"panned", camera{x: tileSize, y: 2 * tileSize}, boardOffset, boardOffset, 2, 1, true},
+		{"zoomed", camera{zoom: 2}, 2 * (boardOffset + tileSize), 2 * boardOffset, 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g.cam = tt.cam
+			i, j, ok := g.cellAt(tt.x, tt.y)
+			if ok != tt.wantOk || (ok && (i != tt.i || j != tt.j)) {
+				t.Errorf("got (%d, %d, %v), want (%d, %d, %v)", i, j, ok, tt.i, tt.j, tt.wantOk)
+			}
+		})
+	}
+}
@@ -2,10 +2,16 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
 	_ "image/png"
+	"io"
 	"io/fs"
 	"math"
+	"path"
+	"slices"
+	"strings"
 	"wfc2/pkg/boiler"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -43,6 +49,7 @@ type Tile struct {
 
 type Image struct {
 	img         *ebiten.Image
+	src         image.Image // the decoded image, for rendering outside of ebiten
 	rotateAngle float64
 }
 
@@ -54,56 +61,108 @@ type Card struct {
 	Id         int
 	Image      *Image
 	Connectors []Connector
+	Base       int // index of the BaseCards entry the card was built from
+	Rotation   int // rotation in degrees applied to the base card
 	chance     int
 }
 
 func (c Card) String() string {
-	str := fmt.Sprintf("{Id: %d, Image: Image{nil, %f}, Connectors: []Connector{%s, %s, %s, %s}},\n", c.Id, c.Image.rotateAngle, c.Connectors[0], c.Connectors[1], c.Connectors[2], c.Connectors[3])
+	str := fmt.Sprintf("{Id: %d, Image: &Image{rotateAngle: %f}, Connectors: []Connector{%s, %s, %s, %s}},\n", c.Id, c.Image.rotateAngle, c.Connectors[0], c.Connectors[1], c.Connectors[2], c.Connectors[3])
 	return str
 }
 
+// ConnectorString returns the connectors in the same form as the rules file, eg "RRGG"
+func (c Card) ConnectorString() string {
+	return connectorString(c.Connectors)
+}
+
 type BaseCards struct {
-	Filename      string
-	ImageLocation []int
-	Connectors    string
-	Rotations     []int
-	Chance        int
+	Filename      string `json:"filename"`
+	ImageLocation []int  `json:"imageLocation"`
+	Connectors    string `json:"connectors"`
+	Rotations     []int  `json:"rotations"`
+	Chance        int    `json:"chance"`
 }
 
 func BuildCards(rules BasicRules, fs fs.FS) map[int]*Card {
+	cards, err := LoadCards(rules, fs)
+	if err != nil {
+		fmt.Println(err)
+		panic(err)
+	}
+
+	return cards
+}
+
+// LoadCards builds the cards, and their rotations, from the base cards in the rules
+// returning an error rather than panicking if an image can't be read
+func LoadCards(rules BasicRules, fs fs.FS) (map[int]*Card, error) {
+	return loadCards(rules, fs, true)
+}
+
+// LoadCardsHeadless is LoadCards without creating ebiten images, for rendering outside of a window
+// such as in the server, the cards can be rendered with RenderImage but not drawn to the screen
+func LoadCardsHeadless(rules BasicRules, fs fs.FS) (map[int]*Card, error) {
+	return loadCards(rules, fs, false)
+}
+
+func loadCards(rules BasicRules, fs fs.FS, ebitenImages bool) (map[int]*Card, error) {
 
 	cards := make(map[int]*Card)
 
 	id := 1
-	var img *ebiten.Image
-	var err error
-	for _, baseCard := range rules.BaseCards {
-		if baseCard.Filename != "" {
-			img, _, err = ebitenutil.NewImageFromFileSystem(fs, baseCard.Filename)
+	for base, baseCard := range rules.BaseCards {
+		var img *ebiten.Image
+		var src image.Image
+		var err error
+		if baseCard.Filename != "" && ebitenImages {
+			img, src, err = ebitenutil.NewImageFromFileSystem(fs, baseCard.Filename)
+			if err != nil {
+				return nil, fmt.Errorf("error reading image file %s: %w", baseCard.Filename, err)
+			}
+		} else if baseCard.Filename != "" {
+			src, err = decodeImage(fs, baseCard.Filename)
 			if err != nil {
-				fmt.Printf("error reading image file %s: %v", baseCard.Filename, err)
-				panic(err)
+				return nil, fmt.Errorf("error reading image file %s: %w", baseCard.Filename, err)
 			}
 		}
-		image := Image{
+		cardImage := Image{
 			img: img,
+			src: src,
 		}
 		card := Card{
 			Id:         id,
-			Image:      &image,
+			Image:      &cardImage,
 			Connectors: convertConnections(baseCard.Connectors),
+			Base:       base,
 			chance:     baseCard.Chance,
 		}
 		cards[card.Id] = &card
 		id++
 		for _, rotation := range baseCard.Rotations {
-			rotCard := rotateCard(card, rotation, id)
+			var rotCard Card
+			if rules.Topology == Voxel {
+				rotCard = rotateVoxelCard(card, rotation, id)
+			} else {
+				rotCard = rotateCard(card, rotation, id)
+			}
 			cards[rotCard.Id] = &rotCard
 			id++
 		}
 	}
 
-	return cards
+	return cards, nil
+}
+
+// decodeImage reads an image from the filesystem without making an ebiten image of it
+func decodeImage(fsys fs.FS, filename string) (image.Image, error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
 }
 
 func convertConnections(connections string) []Connector {
@@ -121,6 +180,24 @@ func convertConnections(connections string) []Connector {
 	return connectors
 }
 
+// connectorString is the reverse of convertConnections
+func connectorString(connectors []Connector) string {
+	var str []rune
+
+	for _, c := range connectors {
+		switch c {
+		case Grass:
+			str = append(str, 'G')
+		case Road:
+			str = append(str, 'R')
+		default:
+			str = append(str, '?')
+		}
+	}
+
+	return string(str)
+}
+
 func LoadRules(filename string, fs fs.FS) BasicRules {
 	string, err := boiler.ReadJsonFromDisk(fs, filename)
 	if err != nil {
@@ -128,9 +205,7 @@ func LoadRules(filename string, fs fs.FS) BasicRules {
 		panic(err)
 	}
 
-	var rules BasicRules
-
-	err = json.Unmarshal([]byte(string), &rules)
+	rules, err := ParseRules([]byte(string))
 	if err != nil {
 		fmt.Println("error unmarshalling rules file:", err)
 		panic(err)
@@ -139,11 +214,178 @@ func LoadRules(filename string, fs fs.FS) BasicRules {
 	return rules
 }
 
+// ListRules returns the names of the rules files in dir, without the .json extension
+func ListRules(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && path.Ext(entry.Name()) == ".json" {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	return names, nil
+}
+
+// WriteRules writes the rules as indented json, in the same form LoadRules reads
+func WriteRules(w io.Writer, rules BasicRules) error {
+	data, err := json.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ParseRules unmarshals a rules file, it does not check that the rules make sense, use Validate for that
+func ParseRules(data []byte) (BasicRules, error) {
+	var rules BasicRules
+
+	err := json.Unmarshal(data, &rules)
+	if err != nil {
+		return BasicRules{}, err
+	}
+
+	return rules, nil
+}
+
+// Validate checks the rules can be used to build cards and a board
+// every problem found is returned, joined into a single error
+func (r BasicRules) Validate(fsys fs.FS) error {
+	var errs []error
+
+	if r.ImageSize <= 0 {
+		errs = append(errs, fmt.Errorf("imageSize must be positive, got %d", r.ImageSize))
+	}
+	if r.BoardWidth <= 0 || r.BoardHeight <= 0 {
+		errs = append(errs, fmt.Errorf("board must be at least 1x1, got %dx%d", r.BoardWidth, r.BoardHeight))
+	}
+	if r.Randomiser != Basic && r.Randomiser != SimpleWeighted {
+		errs = append(errs, fmt.Errorf("unknown randomiser %d", r.Randomiser))
+	}
+	if r.Wrap < WrapNone || r.Wrap > WrapBoth {
+		errs = append(errs, fmt.Errorf("unknown wrap %d", r.Wrap))
+	}
+	for side, name := range []string{"north", "east", "south", "west"} {
+		rule := r.Borders.side(side)
+		wraps := r.Wrap&WrapVertical != 0
+		length := r.BoardHeight
+		if side == 1 || side == 3 {
+			wraps = r.Wrap&WrapHorizontal != 0
+			length = r.BoardWidth
+		}
+		if rule.Connector != "" && rule.Connector != "G" && rule.Connector != "R" {
+			errs = append(errs, fmt.Errorf("%s border: connector must be G, R or empty, got %q", name, rule.Connector))
+		}
+		if wraps && (rule.Connector != "" || len(rule.Exits) > 0) {
+			errs = append(errs, fmt.Errorf("%s border: the edge wraps so can't have a border rule", name))
+		}
+		for _, exit := range rule.Exits {
+			if exit < 0 || exit >= length {
+				errs = append(errs, fmt.Errorf("%s border: exit %d is off the edge", name, exit))
+			}
+		}
+	}
+	if len(r.BaseCards) == 0 {
+		errs = append(errs, errors.New("no base cards"))
+	}
+
+	if r.Topology != Square && r.Topology != Hex && r.Topology != Voxel {
+		errs = append(errs, fmt.Errorf("unknown topology %d", r.Topology))
+	}
+	if r.Topology == Voxel && r.BoardDepth <= 0 {
+		errs = append(errs, fmt.Errorf("voxels need at least one layer, got boardDepth %d", r.BoardDepth))
+	}
+	if r.Topology == Voxel && r.ConnectedRoads {
+		errs = append(errs, errors.New("connectedRoads isn't supported for voxels"))
+	}
+	if r.Topology == Voxel && r.Wrap != WrapNone {
+		errs = append(errs, errors.New("wrap isn't supported for voxels"))
+	}
+	if r.Topology == Voxel && !r.Borders.empty() {
+		errs = append(errs, errors.New("borders aren't supported for voxels"))
+	}
+	if r.Topology == Voxel && (len(r.Mask) > 0 || r.MaskFile != "") {
+		errs = append(errs, errors.New("masks aren't supported for voxels"))
+	}
+
+	sides := r.Topology.Sides()
+	step := r.Topology.rotationStep()
+	cardCount := 0
+	for i, baseCard := range r.BaseCards {
+		cardCount += 1 + len(baseCard.Rotations)
+
+		if len(baseCard.Connectors) != sides || len(convertConnections(baseCard.Connectors)) != sides {
+			errs = append(errs, fmt.Errorf("base card %d: connectors must be %d of G or R, got %q", i, sides, baseCard.Connectors))
+		}
+		for _, rotation := range baseCard.Rotations {
+			if rotation <= 0 || rotation >= 360 || rotation%step != 0 {
+				errs = append(errs, fmt.Errorf("base card %d: rotation must be a multiple of %d between 0 and 360, got %d", i, step, rotation))
+			}
+		}
+		if baseCard.Chance < 0 || (r.Randomiser == SimpleWeighted && baseCard.Chance == 0) {
+			errs = append(errs, fmt.Errorf("base card %d: chance must be positive, got %d", i, baseCard.Chance))
+		}
+		if baseCard.Filename != "" {
+			_, err := fs.Stat(fsys, baseCard.Filename)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("base card %d: %w", i, err))
+			}
+		}
+	}
+
+	if len(r.Adjacency) > 0 && r.Topology != Square {
+		errs = append(errs, fmt.Errorf("adjacency rules need square cards, not %s", r.Topology))
+	}
+	for k, rule := range r.Adjacency {
+		if rule.Card < 1 || rule.Card > cardCount || rule.Neighbour < 1 || rule.Neighbour > cardCount {
+			errs = append(errs, fmt.Errorf("adjacency rule %d: unknown card id %d or %d", k, rule.Card, rule.Neighbour))
+		}
+		if !slices.Contains(sideNames, rule.Side) {
+			errs = append(errs, fmt.Errorf("adjacency rule %d: side must be one of %v, got %q", k, sideNames, rule.Side))
+		}
+	}
+
+	if r.MaskConnector != "" && r.MaskConnector != "G" && r.MaskConnector != "R" {
+		errs = append(errs, fmt.Errorf("maskConnector must be G, R or empty, got %q", r.MaskConnector))
+	}
+	mask, err := r.LoadMask(fsys)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, seedTile := range r.SeedTiles {
+		if seedTile.X < 0 || seedTile.X >= r.BoardWidth || seedTile.Y < 0 || seedTile.Y >= r.BoardHeight {
+			errs = append(errs, fmt.Errorf("seed tile (%d, %d) is off the board", seedTile.X, seedTile.Y))
+		} else if mask != nil && mask[seedTile.X][seedTile.Y] {
+			errs = append(errs, fmt.Errorf("seed tile (%d, %d) is outside the mask", seedTile.X, seedTile.Y))
+		}
+		if seedTile.Id < 1 || seedTile.Id > cardCount {
+			errs = append(errs, fmt.Errorf("seed tile (%d, %d) has unknown card id %d", seedTile.X, seedTile.Y, seedTile.Id))
+		}
+	}
+
+	for _, seed := range r.SeedVoxels {
+		if seed.X < 0 || seed.X >= r.BoardWidth || seed.Y < 0 || seed.Y >= r.BoardHeight || seed.Z < 0 || seed.Z >= r.BoardDepth {
+			errs = append(errs, fmt.Errorf("seed voxel (%d, %d, %d) is off the board", seed.X, seed.Y, seed.Z))
+		}
+		if seed.Id < 1 || seed.Id > cardCount {
+			errs = append(errs, fmt.Errorf("seed voxel (%d, %d, %d) has unknown card id %d", seed.X, seed.Y, seed.Z, seed.Id))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func rotateCard(card Card, rotation, id int) Card {
 
 	rad := float64(rotation) * math.Pi / 180.00
 	rotImage := &Image{
 		img:         card.Image.img,
+		src:         card.Image.src,
 		rotateAngle: rad,
 	}
 
@@ -151,6 +393,8 @@ func rotateCard(card Card, rotation, id int) Card {
 		Id:         id,
 		Image:      rotImage,
 		Connectors: rotateConnections(card.Connectors, rotation),
+		Base:       card.Base,
+		Rotation:   rotation,
 		chance:     card.chance,
 	}
 	return rotCard
@@ -158,7 +402,7 @@ func rotateCard(card Card, rotation, id int) Card {
 
 func rotateConnections(connectors []Connector, rotation int) []Connector {
 	rotated := make([]Connector, len(connectors))
-	rot := rotation / 90
+	rot := rotation / (360 / len(connectors))
 	for i, c := range connectors {
 		rotated[(i+rot)%len(connectors)] = c
 	}
@@ -194,15 +438,24 @@ func NewBasicRandom(seed uint64) Rnd {
 	return rand.New(s)
 }
 
-func (t Tile) Draw(screen *ebiten.Image) {
+// Draw draws the tile in its cell, cam then moves the board onto the screen
+func (t Tile) Draw(screen *ebiten.Image, cam ebiten.GeoM) {
 	if t.Card != nil {
-		op := &ebiten.DrawImageOptions{}
-		xPos := (t.Y * 32) + 48
-		yPos := (t.X * 32) + 48
-		op.GeoM.Translate(-16.0, -16.0)
-		op.GeoM.Rotate(t.Card.Image.rotateAngle)
-		op.GeoM.Translate(float64(xPos), float64(yPos))
-		screen.DrawImage(t.Card.Image.img, op)
+		xPos, yPos := squarePosition(t.X, t.Y)
+		drawCard(screen, t.Card, xPos, yPos, cam, &ebiten.DrawImageOptions{})
 	}
 
 }
+
+// drawCard draws the card's image rotated with its top left at (xPos, yPos), and then moved by cam
+// op can carry any colour or blend settings
+func drawCard(screen *ebiten.Image, card *Card, xPos, yPos float64, cam ebiten.GeoM, op *ebiten.DrawImageOptions) {
+	if card.Image == nil || card.Image.img == nil {
+		return
+	}
+	op.GeoM.Translate(-tileSize/2, -tileSize/2)
+	op.GeoM.Rotate(card.Image.rotateAngle)
+	op.GeoM.Translate(xPos+tileSize/2, yPos+tileSize/2)
+	op.GeoM.Concat(cam)
+	screen.DrawImage(card.Image.img, op)
+}
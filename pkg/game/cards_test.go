@@ -1,8 +1,12 @@
 package game
 
 import (
+	"bytes"
+	"image"
+	"image/png"
 	"io/fs"
 	"reflect"
+	"strings"
 	"testing"
 	"testing/fstest"
 )
@@ -123,18 +127,18 @@ func Test_BuildCards(t *testing.T) {
 
 	want := make(map[int]*Card)
 
-	want[1] = &Card{Id: 1, Image: &Image{nil, 0.000000}, Connectors: []Connector{Grass, Grass, Grass, Grass}}
-	want[2] = &Card{Id: 2, Image: &Image{nil, 0.000000}, Connectors: []Connector{Road, Grass, Road, Grass}}
-	want[3] = &Card{Id: 3, Image: &Image{nil, 1.5707963267948966}, Connectors: []Connector{Grass, Road, Grass, Road}}
-	want[4] = &Card{Id: 4, Image: &Image{nil, 0.000000}, Connectors: []Connector{Road, Road, Road, Road}}
-	want[5] = &Card{Id: 5, Image: &Image{nil, 0.000000}, Connectors: []Connector{Road, Road, Grass, Grass}}
-	want[6] = &Card{Id: 6, Image: &Image{nil, 1.5707963267948966}, Connectors: []Connector{Grass, Road, Road, Grass}}
-	want[7] = &Card{Id: 7, Image: &Image{nil, 3.141592653589793}, Connectors: []Connector{Grass, Grass, Road, Road}}
-	want[8] = &Card{Id: 8, Image: &Image{nil, 4.71238898038469}, Connectors: []Connector{Road, Grass, Grass, Road}}
-	want[9] = &Card{Id: 9, Image: &Image{nil, 0.000000}, Connectors: []Connector{Grass, Grass, Grass, Road}}
-	want[10] = &Card{Id: 10, Image: &Image{nil, 1.5707963267948966}, Connectors: []Connector{Road, Grass, Grass, Grass}}
-	want[11] = &Card{Id: 11, Image: &Image{nil, 3.141592653589793}, Connectors: []Connector{Grass, Road, Grass, Grass}}
-	want[12] = &Card{Id: 12, Image: &Image{nil, 4.71238898038469}, Connectors: []Connector{Grass, Grass, Road, Grass}}
+	want[1] = &Card{Id: 1, Image: &Image{rotateAngle: 0.000000}, Connectors: []Connector{Grass, Grass, Grass, Grass}}
+	want[2] = &Card{Id: 2, Image: &Image{rotateAngle: 0.000000}, Connectors: []Connector{Road, Grass, Road, Grass}}
+	want[3] = &Card{Id: 3, Image: &Image{rotateAngle: 1.5707963267948966}, Connectors: []Connector{Grass, Road, Grass, Road}}
+	want[4] = &Card{Id: 4, Image: &Image{rotateAngle: 0.000000}, Connectors: []Connector{Road, Road, Road, Road}}
+	want[5] = &Card{Id: 5, Image: &Image{rotateAngle: 0.000000}, Connectors: []Connector{Road, Road, Grass, Grass}}
+	want[6] = &Card{Id: 6, Image: &Image{rotateAngle: 1.5707963267948966}, Connectors: []Connector{Grass, Road, Road, Grass}}
+	want[7] = &Card{Id: 7, Image: &Image{rotateAngle: 3.141592653589793}, Connectors: []Connector{Grass, Grass, Road, Road}}
+	want[8] = &Card{Id: 8, Image: &Image{rotateAngle: 4.71238898038469}, Connectors: []Connector{Road, Grass, Grass, Road}}
+	want[9] = &Card{Id: 9, Image: &Image{rotateAngle: 0.000000}, Connectors: []Connector{Grass, Grass, Grass, Road}}
+	want[10] = &Card{Id: 10, Image: &Image{rotateAngle: 1.5707963267948966}, Connectors: []Connector{Road, Grass, Grass, Grass}}
+	want[11] = &Card{Id: 11, Image: &Image{rotateAngle: 3.141592653589793}, Connectors: []Connector{Grass, Road, Grass, Grass}}
+	want[12] = &Card{Id: 12, Image: &Image{rotateAngle: 4.71238898038469}, Connectors: []Connector{Grass, Grass, Road, Grass}}
 
 	if len(want) != len(got) {
 		t.Errorf("loaded different number of records got %d, want %d", len(got), len(want))
@@ -158,6 +162,30 @@ func Test_BuildCards(t *testing.T) {
 	}
 }
 
+func Test_LoadCardsHeadless(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"grass.png": {Data: buf.Bytes()}}
+	rules := BasicRules{ImageSize: 8, BaseCards: []BaseCards{{Filename: "grass.png", Connectors: "GGGG", Rotations: []int{90}}}}
+
+	cards, err := LoadCardsHeadless(rules, fsys)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for id, card := range cards {
+		if card.Image.img != nil || card.Image.src == nil || card.Image.src.Bounds().Dx() != 8 {
+			t.Errorf("card %d should only have the decoded image, got %v", id, card.Image)
+		}
+	}
+
+	rules.BaseCards[0].Filename = "missing.png"
+	if _, err := LoadCardsHeadless(rules, fsys); err == nil {
+		t.Errorf("expected an error for a missing image")
+	}
+}
+
 ///////////////////////////// Helper functions /////////////////////////////////
 
 func getFS() fs.FS {
@@ -184,3 +212,84 @@ func getFS() fs.FS {
 
 	return fs
 }
+
+func Test_Validate(t *testing.T) {
+	fs := getFS()
+
+	t.Run("test rules are valid", func(t *testing.T) {
+		rules := LoadRules("static/rules/basicRules.json", fs)
+		rules.SeedTiles = []SeedTiles{{X: 19, Y: 9, Id: 12}}
+
+		err := rules.Validate(fs)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		rules := BasicRules{
+			ImageSize:   32,
+			BoardWidth:  2,
+			BoardHeight: 2,
+			Randomiser:  SimpleWeighted,
+			BaseCards: []BaseCards{
+				{Filename: "static/images/missing.png", Connectors: "GGXG", Rotations: []int{45}, Chance: 0},
+			},
+			SeedTiles: []SeedTiles{{X: 2, Y: 0, Id: 3}},
+		}
+
+		err := rules.Validate(fs)
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		for _, want := range []string{"missing.png", "connectors", "rotation", "chance", "off the board", "unknown card id 3"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("border rules are checked", func(t *testing.T) {
+		rules := LoadRules("static/rules/basicRules.json", fs)
+		rules.SeedTiles = nil
+		rules.Wrap = WrapHorizontal
+		rules.Borders = Borders{
+			North: BorderRule{Connector: "X"},
+			East:  BorderRule{Connector: "G"},
+			South: BorderRule{Exits: []int{10}},
+		}
+
+		err := rules.Validate(fs)
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		for _, want := range []string{"north border: connector", "east border: the edge wraps", "south border: exit 10"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	})
+}
+
+func Test_WriteRules(t *testing.T) {
+	fs := getFS()
+	want := LoadRules("static/rules/basicRules.json", fs)
+
+	var buf bytes.Buffer
+	err := WriteRules(&buf, want)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"connectors": "RRGG"`) {
+		t.Errorf("rules not written with the same names as the rules file:\n%s", buf.String())
+	}
+
+	got, err := ParseRules(buf.Bytes())
+	if err != nil {
+		t.Fatalf("written rules could not be read back: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -9,34 +9,156 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-func (g *Game) Draw(screen *ebiten.Image) {
+// viewer keys
+//
+//	space  new random seed
+//	p      play / pause the collapse
+//	n      place a single card
+//	]      place twice as many cards each frame
+//	[      place half as many cards each frame
+//	enter  run to the end
+//	h      cycle the overlay between off, candidate count and weighted entropy
+//	u      toggle drawing undecided cells as a blend of their possible cards
+//	i      toggle the inspector, describing the card under the mouse and what can go next to it
+//	escape close the palette
+//
+// the keys for entering and saving seeds are in seeds.go, for moving the camera in camera.go
+// and for the tileset editor in editor.go
+//
+// left clicking a cell opens a palette of the cards that fit there, clicking one locks it in place
+// right clicking a locked cell unlocks it, the board is regenerated around the locked cells
+// shift dragging selects a rectangle of the board which is regenerated with a new seed
+const (
+	keyNewSeed   = ebiten.KeySpace
+	keyPlayPause = ebiten.KeyP
+	keyStep      = ebiten.KeyN
+	keyFaster    = ebiten.KeyBracketRight
+	keySlower    = ebiten.KeyBracketLeft
+	keyRunToEnd  = ebiten.KeyEnter
+	keyOverlay   = ebiten.KeyH
+	keySuperpose = ebiten.KeyU
+	keyClose     = ebiten.KeyEscape
+)
+
+// the board is drawn tileSize pixels per cell, offset from the top left of the board
+const (
+	tileSize    = 32
+	boardOffset = 32
+)
 
-	for _, row := range g.Board {
-		for _, tile := range row {
+func (g *Game) Draw(screen *ebiten.Image) {
 
-			tile.Draw(screen)
+	cam := g.cam.geoM()
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if !g.onScreen(i, j) {
+				continue
+			}
+			if g.Rules.Topology == Hex {
+				g.drawHexTile(screen, tile, cam)
+			} else {
+				tile.Draw(screen, cam)
+			}
 
 		}
 	}
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Seed: %d", g.Seed))
+	g.drawSuperposition(screen)
+	g.drawLocked(screen)
+	g.drawOverlay(screen)
+	g.drawPalette(screen)
+	g.drawSelection(screen)
+	g.drawInspector(screen)
+	g.drawEditor(screen)
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("%s    %s\n%s", g.seedText(), g.progress(), g.status))
 }
 
-func (g Game) Layout(outsideWidth int, outsideHeight int) (screenWidth int, ScreenHeight int) {
-	return 720, 720
+func (g *Game) Layout(outsideWidth int, outsideHeight int) (screenWidth int, ScreenHeight int) {
+	g.screenWidth, g.screenHeight = outsideWidth, outsideHeight
+	return outsideWidth, outsideHeight
 }
 
 func (g *Game) Update() error {
 
-	next := inpututil.IsKeyJustPressed(ebiten.KeySpace)
+	g.updateWatch()
+	if g.updateSeeds() {
+		return nil
+	}
+	if g.updateEditor() {
+		return nil
+	}
+
+	next := inpututil.IsKeyJustPressed(keyNewSeed)
 	if next {
-		seed := rand.Uint64()
-		g.NewSeed(seed)
+		g.viewSeed(rand.Uint64())
+	}
+
+	g.updateCamera()
+	if !g.updateSelection() {
+		g.updatePalette()
+	}
+
+	if inpututil.IsKeyJustPressed(keyPlayPause) {
+		g.playing = !g.playing
+	}
+	if inpututil.IsKeyJustPressed(keyFaster) {
+		g.StepsPerFrame = max(1, g.StepsPerFrame*2)
+	}
+	if inpututil.IsKeyJustPressed(keySlower) {
+		g.StepsPerFrame = max(1, g.StepsPerFrame/2)
+	}
+	if inpututil.IsKeyJustPressed(keyStep) {
+		g.playing = false
+		g.Step(1)
+	}
+	if inpututil.IsKeyJustPressed(keyRunToEnd) {
+		g.playing = false
+		g.Step(g.Rules.BoardWidth * g.Rules.BoardHeight)
+	}
+
+	if inpututil.IsKeyJustPressed(keyOverlay) {
+		g.overlay = (g.overlay + 1) % 3
+	}
+	if inpututil.IsKeyJustPressed(keySuperpose) {
+		g.superposition = !g.superposition
+	}
+	if inpututil.IsKeyJustPressed(keyInspect) {
+		g.inspect = !g.inspect
+	}
 
+	if g.playing && !g.Step(g.StepsPerFrame) {
+		g.playing = false
 	}
 
 	return nil
 }
 
+// restart regenerates the board with the seed, animating it if StepsPerFrame is set
+func (g *Game) restart(seed uint64) {
+	if g.StepsPerFrame > 0 {
+		g.Reset(seed)
+		g.playing = true
+	} else {
+		g.NewSeed(seed)
+	}
+}
+
+// Animate restarts the board with the current seed and plays the collapse StepsPerFrame cards at a time
+func (g *Game) Animate() {
+	g.Reset(g.Seed)
+	g.playing = true
+}
+
+func (g *Game) progress() string {
+	state := "paused"
+	if g.playing {
+		state = "playing"
+	}
+	if g.roadsSplit {
+		state += ", roads split"
+	}
+	return fmt.Sprintf("%d/%d placed, %d per frame, %s, overlay %s", g.Placed(), g.playable(), g.StepsPerFrame, state, g.overlay)
+}
+
 func (g *Game) Draw_debugTiles(screen *ebiten.Image) {
 	for i := 1; i < 13; i++ {
 		card := g.Cards[i]
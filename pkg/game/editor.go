@@ -0,0 +1,396 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// editor keys
+//
+//	tab      open / close the editor
+//	up/down  choose the base card
+//	ctrl+s   save the rules to RulesFile
+//
+// clicking an edge of the preview swaps it between grass and road, the buttons below it
+// toggle the rotations and change the chance, holding shift changes the chance by 10
+const (
+	keyEditor = ebiten.KeyTab
+	keySave   = ebiten.KeyS
+)
+
+// the editor is drawn as a panel down the left hand side of the screen, over the board
+const (
+	editorWidth   = 360
+	editorSpacing = tileSize + 8
+	previewX      = 200
+	previewY      = boardOffset + edgeWidth
+	previewSize   = 128
+	edgeWidth     = 12
+	buttonWidth   = 40
+	buttonHeight  = 20
+)
+
+var (
+	buttonColour   = color.RGBA{64, 64, 64, 255}
+	buttonOnColour = color.RGBA{48, 96, 160, 255}
+)
+
+// editor is the state of the tileset editor, the rules being edited are kept separately
+// from the game's so that they can be invalid while they are being changed
+// they only have the seed tiles from the rules file, not those locked in the viewer, as they are what is saved
+type editor struct {
+	active   bool
+	selected int // the index of the base card being edited
+	rules    BasicRules
+}
+
+// button is a clickable area of the editor
+type button struct {
+	rect   image.Rectangle
+	label  string
+	on     bool
+	action func()
+}
+
+// updateEditor handles the editor keys and mouse, it returns true while the editor is open
+// so that the viewer doesn't also act on the input
+func (g *Game) updateEditor() bool {
+	if !g.editor.active {
+		if inpututil.IsKeyJustPressed(keyEditor) {
+			if g.Rules.Topology != Square {
+				g.status = fmt.Sprintf("the editor can't edit %s cards", g.Rules.Topology)
+				return false
+			}
+			g.editor = editor{active: true, rules: g.editorRules()}
+			g.paletteCell = nil
+		}
+		return g.editor.active
+	}
+
+	if inpututil.IsKeyJustPressed(keyEditor) || inpututil.IsKeyJustPressed(keyClose) {
+		g.editor.active = false
+		return true
+	}
+
+	if inpututil.IsKeyJustPressed(keySave) && (ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)) {
+		g.saveRules()
+		return true
+	}
+
+	baseCards := len(g.editor.rules.BaseCards)
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && baseCards > 0 {
+		g.editor.selected = (g.editor.selected + 1) % baseCards
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && baseCards > 0 {
+		g.editor.selected = (g.editor.selected + baseCards - 1) % baseCards
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		p := image.Pt(ebiten.CursorPosition())
+		for _, b := range g.editorButtons() {
+			if p.In(b.rect) {
+				b.action()
+				break
+			}
+		}
+	}
+
+	return true
+}
+
+// editorButtons returns everything in the editor that can be clicked, it is used for both drawing and clicking
+func (g *Game) editorButtons() []button {
+	var buttons []button
+
+	for base := range g.editor.rules.BaseCards {
+		buttons = append(buttons, button{
+			rect:   image.Rect(8, boardOffset+base*editorSpacing, previewX-edgeWidth-8, boardOffset+base*editorSpacing+tileSize),
+			on:     base == g.editor.selected,
+			action: func() { g.editor.selected = base },
+		})
+	}
+
+	if g.editor.selected >= len(g.editor.rules.BaseCards) {
+		return buttons
+	}
+	baseCard := &g.editor.rules.BaseCards[g.editor.selected]
+
+	// the edges are in the same order as the connectors, north, east, south, west
+	edges := []image.Rectangle{
+		image.Rect(previewX, previewY-edgeWidth, previewX+previewSize, previewY),
+		image.Rect(previewX+previewSize, previewY, previewX+previewSize+edgeWidth, previewY+previewSize),
+		image.Rect(previewX, previewY+previewSize, previewX+previewSize, previewY+previewSize+edgeWidth),
+		image.Rect(previewX-edgeWidth, previewY, previewX, previewY+previewSize),
+	}
+	for side, rect := range edges {
+		buttons = append(buttons, button{
+			rect: rect,
+			action: func() {
+				baseCard.Connectors = toggleConnector(baseCard.Connectors, side)
+				g.applyEditor()
+			},
+		})
+	}
+
+	y := previewY + previewSize + edgeWidth + 16
+	for n, rotation := range []int{90, 180, 270} {
+		x := previewX + n*(buttonWidth+4)
+		buttons = append(buttons, button{
+			rect:  image.Rect(x, y, x+buttonWidth, y+buttonHeight),
+			label: fmt.Sprint(rotation),
+			on:    slices.Contains(baseCard.Rotations, rotation),
+			action: func() {
+				before := cloneRules(g.editor.rules)
+				baseCard.Rotations = toggleRotation(baseCard.Rotations, rotation)
+				dropped := remapRules(&g.editor.rules, remapCardIds(before, g.editor.rules))
+				g.applyEditor()
+				if dropped > 0 && g.status == "" {
+					g.status = fmt.Sprintf("removed %d seed tiles and adjacency rules using the rotation", dropped)
+				}
+			},
+		})
+	}
+
+	y += buttonHeight + 8
+	for n, sign := range []int{-1, 1} {
+		x := previewX + n*2*(buttonWidth+4)
+		label := "-"
+		if sign > 0 {
+			label = "+"
+		}
+		buttons = append(buttons, button{
+			rect:  image.Rect(x, y, x+buttonWidth, y+buttonHeight),
+			label: label,
+			action: func() {
+				step := 1
+				if ebiten.IsKeyPressed(ebiten.KeyShift) {
+					step = 10
+				}
+				baseCard.Chance = max(0, baseCard.Chance+sign*step)
+				g.applyEditor()
+			},
+		})
+	}
+
+	return buttons
+}
+
+// applyEditor rebuilds the cards from the edited rules and regenerates the board with the same seed
+// if the rules aren't valid the game keeps its current cards and the problem is shown in the status
+func (g *Game) applyEditor() {
+	rules := cloneRules(g.editor.rules)
+	err := rules.Validate(g.Fs)
+	if err == nil && rules.Topology == Voxel {
+		err = ErrVoxelBoard
+	}
+	if err != nil {
+		g.status = fmt.Sprintf("invalid rules: %v", err)
+		return
+	}
+	cards, err := LoadCards(rules, g.Fs)
+	if err != nil {
+		g.status = fmt.Sprintf("invalid rules: %v", err)
+		return
+	}
+	g.replaceRules(rules, cards, remapCardIds(g.Rules, rules))
+	g.status = ""
+	g.restart(g.Seed)
+}
+
+// editorRules returns a copy of the game's rules for the editor, with the seed tiles from the rules file
+func (g *Game) editorRules() BasicRules {
+	rules := cloneRules(g.Rules)
+	rules.SeedTiles = slices.Clone(g.rulesSeeds)
+	return rules
+}
+
+// saveRules writes the edited rules out to RulesFile, rules that don't validate aren't saved
+// as the game wouldn't be able to load them
+func (g *Game) saveRules() {
+	if g.RulesFile == "" {
+		g.status = "no rules file to save to"
+		return
+	}
+	err := g.editor.rules.Validate(g.Fs)
+	if err != nil {
+		g.status = fmt.Sprintf("not saved, invalid rules: %v", err)
+		return
+	}
+
+	f, err := os.Create(g.RulesFile)
+	if err != nil {
+		g.status = fmt.Sprintf("save failed: %v", err)
+		return
+	}
+	defer f.Close()
+
+	err = WriteRules(f, g.editor.rules)
+	if err != nil {
+		g.status = fmt.Sprintf("save failed: %v", err)
+		return
+	}
+	g.status = fmt.Sprintf("saved %s", g.RulesFile)
+}
+
+func (g *Game) drawEditor(screen *ebiten.Image) {
+	if !g.editor.active {
+		return
+	}
+
+	vector.DrawFilledRect(screen, 0, 0, editorWidth, float32(g.screenHeight), paletteColour, false)
+
+	for _, b := range g.editorButtons() {
+		x, y := float32(b.rect.Min.X), float32(b.rect.Min.Y)
+		w, h := float32(b.rect.Dx()), float32(b.rect.Dy())
+		switch {
+		case b.label != "":
+			fill := buttonColour
+			if b.on {
+				fill = buttonOnColour
+			}
+			vector.DrawFilledRect(screen, x, y, w, h, fill, false)
+			ebitenutil.DebugPrintAt(screen, b.label, b.rect.Min.X+4, b.rect.Min.Y+2)
+		case b.on:
+			vector.StrokeRect(screen, x-2, y-2, w+4, h+4, 1, selectedColour, false)
+		}
+	}
+
+	for base, baseCard := range g.editor.rules.BaseCards {
+		y := boardOffset + base*editorSpacing
+		if card := g.baseCard(base); card != nil {
+			drawCard(screen, card, 8, float64(y), ebiten.GeoM{}, &ebiten.DrawImageOptions{})
+		}
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d %s\nchance %d", base, baseCard.Connectors, baseCard.Chance), 8+tileSize+8, y)
+	}
+
+	if g.editor.selected >= len(g.editor.rules.BaseCards) {
+		return
+	}
+	baseCard := g.editor.rules.BaseCards[g.editor.selected]
+	if card := g.baseCard(g.editor.selected); card != nil && card.Image != nil && card.Image.img != nil {
+		op := &ebiten.DrawImageOptions{}
+		scale := float64(previewSize) / float64(card.Image.img.Bounds().Dx())
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(previewX, previewY)
+		screen.DrawImage(card.Image.img, op)
+	}
+
+	sides := []struct{ x, y, w, h float32 }{
+		{previewX, previewY - edgeWidth, previewSize, edgeWidth},
+		{previewX + previewSize, previewY, edgeWidth, previewSize},
+		{previewX, previewY + previewSize, previewSize, edgeWidth},
+		{previewX - edgeWidth, previewY, edgeWidth, previewSize},
+	}
+	for side, r := range sides {
+		fill := grassColour
+		if side < len(baseCard.Connectors) && baseCard.Connectors[side] == 'R' {
+			fill = roadColour
+		}
+		vector.DrawFilledRect(screen, r.x, r.y, r.w, r.h, fill, false)
+	}
+
+	y := previewY + previewSize + edgeWidth + 16 + buttonHeight + 8
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", baseCard.Chance), previewX+buttonWidth+12, y+2)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s\n\nclick an edge to swap grass and road\nctrl+s save, tab close", baseCard.Filename), 8, g.screenHeight-64)
+}
+
+// toggleConnector swaps the connector on the side between grass and road
+func toggleConnector(connectors string, side int) string {
+	c := []byte(connectors)
+	if side < 0 || side >= len(c) {
+		return connectors
+	}
+	if c[side] == 'R' {
+		c[side] = 'G'
+	} else {
+		c[side] = 'R'
+	}
+	return string(c)
+}
+
+// toggleRotation adds the rotation if it isn't there and removes it if it is, keeping them in order
+func toggleRotation(rotations []int, rotation int) []int {
+	if i := slices.Index(rotations, rotation); i >= 0 {
+		return slices.Delete(slices.Clone(rotations), i, i+1)
+	}
+	rotations = append(slices.Clone(rotations), rotation)
+	slices.Sort(rotations)
+	return rotations
+}
+
+// cardIds returns the id of each card, by its base card and rotation, numbered the same way as LoadCards
+// a base card has rotation 0
+func cardIds(rules BasicRules) map[[2]int]int {
+	ids := map[[2]int]int{}
+	id := 1
+	for base, baseCard := range rules.BaseCards {
+		ids[[2]int{base, 0}] = id
+		id++
+		for _, rotation := range baseCard.Rotations {
+			ids[[2]int{base, rotation}] = id
+			id++
+		}
+	}
+	return ids
+}
+
+// remapCardIds returns the id in the to rules of each card from the from rules, matching them by base card
+// and rotation, cards that aren't in the to rules are left out
+func remapCardIds(from, to BasicRules) map[int]int {
+	toIds := cardIds(to)
+	ids := map[int]int{}
+	for key, id := range cardIds(from) {
+		if newId, ok := toIds[key]; ok {
+			ids[id] = newId
+		}
+	}
+	return ids
+}
+
+// remapRules changes the card ids in the seed tiles and adjacency rules to the new ones in ids
+// removing those with a card that isn't in ids, it returns how many were removed
+func remapRules(rules *BasicRules, ids map[int]int) int {
+	before := len(rules.SeedTiles) + len(rules.Adjacency)
+	rules.SeedTiles = slices.DeleteFunc(rules.SeedTiles, func(s SeedTiles) bool {
+		_, ok := ids[s.Id]
+		return !ok
+	})
+	for k := range rules.SeedTiles {
+		rules.SeedTiles[k].Id = ids[rules.SeedTiles[k].Id]
+	}
+	rules.Adjacency = slices.DeleteFunc(rules.Adjacency, func(rule AdjacencyRule) bool {
+		_, ok := ids[rule.Card]
+		_, neighbourOk := ids[rule.Neighbour]
+		return !ok || !neighbourOk
+	})
+	for k := range rules.Adjacency {
+		rules.Adjacency[k].Card = ids[rules.Adjacency[k].Card]
+		rules.Adjacency[k].Neighbour = ids[rules.Adjacency[k].Neighbour]
+	}
+	return before - len(rules.SeedTiles) - len(rules.Adjacency)
+}
+
+// cloneRules copies the rules so that editing the copy doesn't change the original
+func cloneRules(rules BasicRules) BasicRules {
+	rules.BaseCards = slices.Clone(rules.BaseCards)
+	for i := range rules.BaseCards {
+		rules.BaseCards[i].ImageLocation = slices.Clone(rules.BaseCards[i].ImageLocation)
+		rules.BaseCards[i].Rotations = slices.Clone(rules.BaseCards[i].Rotations)
+	}
+	rules.SeedTiles = slices.Clone(rules.SeedTiles)
+	rules.SeedVoxels = slices.Clone(rules.SeedVoxels)
+	rules.Mask = slices.Clone(rules.Mask)
+	rules.Adjacency = slices.Clone(rules.Adjacency)
+	for _, rule := range []*BorderRule{&rules.Borders.North, &rules.Borders.East, &rules.Borders.South, &rules.Borders.West} {
+		rule.Exits = slices.Clone(rule.Exits)
+	}
+	return rules
+}
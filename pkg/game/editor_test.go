@@ -0,0 +1,124 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_toggleConnector(t *testing.T) {
+	tests := []struct {
+		connectors string
+		side       int
+		want       string
+	}{
+		{"GGGG", 0, "RGGG"},
+		{"RGRG", 2, "RGGG"},
+		{"RRGG", 3, "RRGR"},
+		{"RRGG", 4, "RRGG"},
+	}
+	for _, tt := range tests {
+		got := toggleConnector(tt.connectors, tt.side)
+		if got != tt.want {
+			t.Errorf("toggleConnector(%q, %d) = %q, want %q", tt.connectors, tt.side, got, tt.want)
+		}
+	}
+}
+
+func Test_toggleRotation(t *testing.T) {
+	rotations := []int{90, 270}
+
+	got := toggleRotation(rotations, 180)
+	if want := []int{90, 180, 270}; !reflect.DeepEqual(got, want) {
+		t.Errorf("adding 180 got %v, want %v", got, want)
+	}
+
+	got = toggleRotation(got, 90)
+	if want := []int{180, 270}; !reflect.DeepEqual(got, want) {
+		t.Errorf("removing 90 got %v, want %v", got, want)
+	}
+
+	if want := []int{90, 270}; !reflect.DeepEqual(rotations, want) {
+		t.Errorf("original rotations changed to %v", rotations)
+	}
+}
+
+func Test_cloneRules(t *testing.T) {
+	rules := LoadRules("static/rules/basicRules.json", getFS())
+	want := LoadRules("static/rules/basicRules.json", getFS())
+	clone := cloneRules(rules)
+
+	clone.BaseCards[1].Connectors = "GGGG"
+	clone.BaseCards[1].Rotations[0] = 180
+	clone.SeedTiles[0].Id = 2
+
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("editing the clone changed the original rules to %v, want %v", rules, want)
+	}
+}
+
+func Test_remapRules(t *testing.T) {
+	before := BasicRules{
+		BaseCards: []BaseCards{
+			{Connectors: "GGGG"},
+			{Connectors: "RGRG", Rotations: []int{90}},
+			{Connectors: "RRGG", Rotations: []int{90, 180, 270}},
+		},
+		SeedTiles: []SeedTiles{{0, 0, 3}, {1, 1, 5}},
+		Adjacency: []AdjacencyRule{{Card: 4, Side: "N", Neighbour: 3}, {Card: 7, Side: "E", Neighbour: 1, Count: 2}},
+	}
+	after := cloneRules(before)
+	after.BaseCards[1].Rotations = nil
+
+	// card 3 was the straight turned 90, which is gone, and the corners move down one
+	dropped := remapRules(&after, remapCardIds(before, after))
+	if dropped != 2 {
+		t.Errorf("dropped got %d, want 2", dropped)
+	}
+	if want := []SeedTiles{{1, 1, 4}}; !reflect.DeepEqual(after.SeedTiles, want) {
+		t.Errorf("seed tiles got %v, want %v", after.SeedTiles, want)
+	}
+	if want := []AdjacencyRule{{Card: 6, Side: "E", Neighbour: 1, Count: 2}}; !reflect.DeepEqual(after.Adjacency, want) {
+		t.Errorf("adjacency got %v, want %v", after.Adjacency, want)
+	}
+}
+
+func Test_editorLockedTiles(t *testing.T) {
+	fsys := getFS().(fstest.MapFS)
+	file := fsys[DefaultRulesFile]
+	file.Data = []byte(strings.Replace(string(file.Data), `"id":0`, `"id":1`, 1))
+	g := NewGame(fsys, 42)
+	g.RulesFile = filepath.Join(t.TempDir(), "rules.json")
+
+	// the cross, which is the fourth card until the straight's rotation is removed
+	g.LockTile(5, 5, 4)
+	g.editor = editor{active: true, rules: g.editorRules()}
+	if want := []SeedTiles{{0, 0, 1}}; !reflect.DeepEqual(g.editor.rules.SeedTiles, want) {
+		t.Errorf("editor seed tiles got %v, want only the file's %v", g.editor.rules.SeedTiles, want)
+	}
+
+	g.editor.rules.BaseCards[1].Rotations = nil
+	g.applyEditor()
+	if want := []SeedTiles{{0, 0, 1}, {5, 5, 3}}; !reflect.DeepEqual(g.Rules.SeedTiles, want) {
+		t.Errorf("seed tiles got %v, want %v", g.Rules.SeedTiles, want)
+	}
+	if g.Board[5][5].Card == nil || g.Board[5][5].Card.Base != 2 {
+		t.Errorf("locked cross not kept, got %v", g.Board[5][5].Card)
+	}
+
+	g.saveRules()
+	data, err := os.ReadFile(g.RulesFile)
+	if err != nil {
+		t.Fatalf("rules not saved: %v, status %q", err, g.status)
+	}
+	saved, err := ParseRules(data)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if want := []SeedTiles{{0, 0, 1}}; !reflect.DeepEqual(saved.SeedTiles, want) {
+		t.Errorf("saved seed tiles got %v, want only the file's %v", saved.SeedTiles, want)
+	}
+}
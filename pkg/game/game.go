@@ -1,8 +1,11 @@
 package game
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"slices"
 	"time"
 
 	"golang.org/x/exp/rand"
@@ -15,6 +18,36 @@ type Game struct {
 	Board [][]Tile
 	R     Rnd
 	Seed  uint64
+
+	// StepsPerFrame is how many cards the viewer places each frame, 0 generates the board instantly
+	StepsPerFrame int
+
+	// RulesFile is where the editor saves the rules, relative to the working directory
+	RulesFile string
+
+	buildBoard    [][]buildCell  // the build state of the board while it is being stepped through
+	pending       *choice        // the next card to be placed, once it has been picked
+	edges         [4][]Connector // the connectors needed off each edge, overriding the border rules, nil for none
+	mask          [][]bool       // the cells outside the playable area, nil for none
+	adjacency     *adjacency     // the cards allowed next to each other, nil for any with matching connectors
+	roadsSplit    bool           // a card had to be placed that cut off part of the roads, with ConnectedRoads
+	rulesSeeds    []SeedTiles    // the seed tiles the rules came with, the rest were locked in the viewer
+	playing       bool
+	overlay       overlayMode
+	superposition bool
+	inspect       bool
+	paletteCell   *cellPos // the cell the palette is choosing a card for
+	palette       []int
+	selectFrom    *cellPos // the corner a region selection was started from
+	selectTo      cellPos
+	status        string // a message shown to the user in the viewer
+	cam           camera
+	history       seedHistory
+	editor        editor
+	watch         *watcher // watches the rules and images for changes, nil when not watching
+	typedSeed     *string  // the seed being typed in, nil when not typing
+	screenWidth   int
+	screenHeight  int
 }
 
 type Randomiser int
@@ -24,19 +57,81 @@ const (
 	SimpleWeighted                   // use the chance field to determine the weight of the card
 )
 
+// Wrap is which edges of the board join the opposite edge, so the generated map tiles seamlessly
+type Wrap int
+
+const (
+	WrapNone       Wrap = 0
+	WrapHorizontal Wrap = 1                             // the east and west edges join
+	WrapVertical   Wrap = 2                             // the north and south edges join
+	WrapBoth       Wrap = WrapHorizontal | WrapVertical // the board is a torus
+)
+
+// Topology is the shape of the cells, which sets how many connectors each card has
+type Topology int
+
+const (
+	Square Topology = iota // four sides, north, east, south, west
+	Hex                    // six sides of a flat topped hexagon, clockwise from north
+	Voxel                  // six faces of a cube, north, east, south, west, up, down, generated with Voxels
+)
+
+// BorderRule constrains the connectors along one edge of the board
+// Connector is "G" or "R" to force every cell on the edge to that connector, or empty to allow anything
+// Exits are the positions along the edge, counted from the north or west end, that must be road
+type BorderRule struct {
+	Connector string `json:"connector"`
+	Exits     []int  `json:"exits"`
+}
+
+// Borders are the rules for each edge of the board, edges that wrap ignore them
+type Borders struct {
+	North BorderRule `json:"north"`
+	East  BorderRule `json:"east"`
+	South BorderRule `json:"south"`
+	West  BorderRule `json:"west"`
+}
+
 type SeedTiles struct {
-	X  int
-	Y  int
-	Id int
+	X  int `json:"x"`
+	Y  int `json:"y"`
+	Id int `json:"id"`
+}
+
+// SeedVoxels are the seed tiles of voxels, Z is the layer
+type SeedVoxels struct {
+	X  int `json:"x"`
+	Y  int `json:"y"`
+	Z  int `json:"z"`
+	Id int `json:"id"`
 }
 
 type BasicRules struct {
-	ImageSize   int
-	BoardWidth  int
-	BoardHeight int
-	BaseCards   []BaseCards
-	SeedTiles   []SeedTiles
-	Randomiser  Randomiser
+	ImageSize   int          `json:"imageSize"`
+	BoardWidth  int          `json:"boardWidth"`
+	BoardHeight int          `json:"boardHeight"`
+	BoardDepth  int          `json:"boardDepth,omitempty"` // the number of layers, for voxels
+	BaseCards   []BaseCards  `json:"baseCards"`
+	SeedTiles   []SeedTiles  `json:"seedTiles"`
+	SeedVoxels  []SeedVoxels `json:"seedVoxels,omitempty"`
+	Randomiser  Randomiser   `json:"randomiser"`
+	Wrap        Wrap         `json:"wrap"`
+	Borders     Borders      `json:"borders"`
+	Topology    Topology     `json:"topology"`
+
+	// Mask marks the cells outside the playable area, '#' for outside and '.' for inside, a string for each row
+	// MaskFile is a png mask instead, with a pixel for each cell, black or transparent pixels are outside
+	// MaskConnector is the connector, "G" or "R", that the cells outside show their neighbours, empty allows anything
+	Mask          []string `json:"mask,omitempty"`
+	MaskFile      string   `json:"maskFile,omitempty"`
+	MaskConnector string   `json:"maskConnector,omitempty"`
+
+	// Adjacency limits which cards can be next to each other, on top of their connectors
+	// LearnRules fills it in from an example board, without any rules cards only need matching connectors
+	Adjacency []AdjacencyRule `json:"adjacency,omitempty"`
+
+	// ConnectedRoads only places cards that leave the roads able to join up, so every road leads somewhere
+	ConnectedRoads bool `json:"connectedRoads,omitempty"`
 }
 
 type Rnd interface {
@@ -49,10 +144,26 @@ func NewSeed(seed uint64) *rand.Rand {
 	return rand.New(s)
 }
 
+// DefaultRulesFile is the rules NewGame loads from its filesystem
+const DefaultRulesFile = "static/rules/basicRules.json"
+
 func NewGame(fs fs.FS, seed uint64) *Game {
 
-	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules := LoadRules(DefaultRulesFile, fs)
 	cards := BuildCards(rules, fs)
+
+	g := NewGameWithRules(fs, rules, cards, seed)
+	g.RulesFile = DefaultRulesFile
+	return g
+}
+
+// NewGameWithRules creates a game from rules and cards that have already been loaded
+// voxel rules can't be used, and panic, as they need a third dimension
+func NewGameWithRules(fs fs.FS, rules BasicRules, cards map[int]*Card, seed uint64) *Game {
+	if rules.Topology == Voxel {
+		fmt.Println("error creating game:", ErrVoxelBoard)
+		panic(ErrVoxelBoard)
+	}
 	tiles := NewBoard(rules, cards)
 
 	// create the random number generator and seed it
@@ -65,15 +176,188 @@ func NewGame(fs fs.FS, seed uint64) *Game {
 		Board: tiles,
 		Seed:  seed,
 		R:     r,
+
+		rulesSeeds: slices.Clone(rules.SeedTiles),
 	}
+	g.prepareRules()
 	return &g
 }
 
 func (g *Game) NewSeed(seed uint64) {
+	g.Reset(seed)
+	g.CreateLandscape()
+}
+
+// Reset clears the board back to its seed tiles ready to be generated with the new seed
+func (g *Game) Reset(seed uint64) {
 	g.R = NewSeed(seed)
 	g.Board = NewBoard(g.Rules, g.Cards)
 	g.Seed = seed
-	g.CreateLandscape()
+	g.buildBoard = getBuildBoard(g)
+	g.pending = nil
+	g.roadsSplit = false
+}
+
+// Step places up to n cards, continuing from where the last step or reset left off
+// it returns false once there are no more cards that can be placed
+func (g *Game) Step(n int) bool {
+	if g.buildBoard == nil {
+		g.buildBoard = getBuildBoard(g)
+		g.pending = nil
+	}
+	for i := 0; i < n; i++ {
+		if !g.evolveBoard(&g.buildBoard) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompatibleCards returns the ids of the cards that could be placed at (x, y)
+// given the cards currently placed around it
+func (g *Game) CompatibleCards(x, y int) []int {
+	buildBoard := getBuildBoard(g)
+	buildBoard[x][y] = g.emptyCell()
+	return g.candidates(buildBoard, x, y)
+}
+
+// LockTile adds the card at (x, y) to the seed tiles, so it is kept when the board is regenerated
+// any card already locked at (x, y) is replaced
+func (g *Game) LockTile(x, y, id int) {
+	seedTiles := make([]SeedTiles, 0, len(g.Rules.SeedTiles)+1)
+	for _, seedTile := range g.Rules.SeedTiles {
+		if seedTile.X != x || seedTile.Y != y {
+			seedTiles = append(seedTiles, seedTile)
+		}
+	}
+	g.Rules.SeedTiles = append(seedTiles, SeedTiles{X: x, Y: y, Id: id})
+}
+
+// UnlockTile removes any seed tile at (x, y)
+func (g *Game) UnlockTile(x, y int) {
+	seedTiles := make([]SeedTiles, 0, len(g.Rules.SeedTiles))
+	for _, seedTile := range g.Rules.SeedTiles {
+		if seedTile.X != x || seedTile.Y != y {
+			seedTiles = append(seedTiles, seedTile)
+		}
+	}
+	g.Rules.SeedTiles = seedTiles
+}
+
+// Locked returns true if there is a seed tile at (x, y)
+func (g *Game) Locked(x, y int) bool {
+	for _, seedTile := range g.Rules.SeedTiles {
+		if seedTile.X == x && seedTile.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedTiles returns the seed tiles that were locked in the viewer, rather than coming with the rules
+func (g *Game) lockedTiles() []SeedTiles {
+	return slices.DeleteFunc(slices.Clone(g.Rules.SeedTiles), func(s SeedTiles) bool {
+		return slices.Contains(g.rulesSeeds, s)
+	})
+}
+
+// replaceRules switches the game to new rules and cards, keeping the tiles locked in the viewer that still fit them
+// ids maps the locked cards to their ids in the new rules, when they have changed, cards missing from it are unlocked
+// it returns how many locked tiles were unlocked
+func (g *Game) replaceRules(rules BasicRules, cards map[int]*Card, ids map[int]int) int {
+	locked := g.lockedTiles()
+	g.Rules = rules
+	g.Cards = cards
+	g.rulesSeeds = slices.Clone(rules.SeedTiles)
+	g.prepareRules()
+
+	dropped := 0
+	for _, s := range locked {
+		id, ok := s.Id, true
+		if ids != nil {
+			id, ok = ids[s.Id]
+		}
+		if !ok || s.X < 0 || s.X >= rules.BoardWidth || s.Y < 0 || s.Y >= rules.BoardHeight || g.Cards[id] == nil || g.Masked(s.X, s.Y) {
+			dropped++
+			continue
+		}
+		g.LockTile(s.X, s.Y, id)
+	}
+	return dropped
+}
+
+// ErrUnsolvable is returned when the cards around a region leave no way of filling it
+var ErrUnsolvable = errors.New("region can't be filled to match the cards around it")
+
+// RerollRegion clears the cells from (x0, y0) to (x1, y1) inclusive and regenerates them using seed,
+// with the rest of the board, and any locked cells in the region, kept as they are
+// if the region can't be filled the board is left unchanged and ErrUnsolvable is returned
+func (g *Game) RerollRegion(x0, y0, x1, y1 int, seed uint64) error {
+	x0, x1 = min(x0, x1), max(x0, x1)
+	y0, y1 = min(y0, y1), max(y0, y1)
+	if x0 < 0 || y0 < 0 || x1 >= g.Rules.BoardWidth || y1 >= g.Rules.BoardHeight {
+		return fmt.Errorf("region (%d, %d) to (%d, %d) is not on the board", x0, y0, x1, y1)
+	}
+	inRegion := func(x, y int) bool {
+		return x >= x0 && x <= x1 && y >= y0 && y <= y1
+	}
+
+	previous := make([][]Tile, len(g.Board))
+	for i, row := range g.Board {
+		previous[i] = append([]Tile(nil), row...)
+	}
+
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			if !g.Locked(x, y) {
+				g.Board[x][y] = Tile{}
+			}
+		}
+	}
+
+	// empty cells outside of the region are left alone, and don't constrain the region
+	buildBoard := getBuildBoard(g)
+	for x, row := range buildBoard {
+		for y, cell := range row {
+			if !cell.placed && !inRegion(x, y) {
+				buildBoard[x][y] = buildCell{placed: true, connectors: g.emptyCell().connectors}
+			}
+		}
+	}
+
+	roadsSplit := g.roadsSplit
+	g.R = NewSeed(seed)
+	g.pending = nil
+	g.roadsSplit = false
+	for g.evolveBoard(&buildBoard) {
+	}
+
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			if g.Board[x][y].Card == nil && !g.Masked(x, y) {
+				g.Board = previous
+				g.buildBoard = nil
+				g.roadsSplit = roadsSplit
+				return fmt.Errorf("(%d, %d): %w", x, y, ErrUnsolvable)
+			}
+		}
+	}
+
+	g.buildBoard = nil
+	return nil
+}
+
+// Placed returns the number of cells on the board that have a card
+func (g *Game) Placed() int {
+	placed := 0
+	for _, row := range g.Board {
+		for _, tile := range row {
+			if tile.Card != nil {
+				placed++
+			}
+		}
+	}
+	return placed
 }
 
 func (g *Game) Start() {
@@ -83,20 +367,50 @@ func (g *Game) Start() {
 }
 
 func (g *Game) CreateLandscape() {
-	buildBoard := getBuildBoard(g)
+	startTime := time.Now()
+	cnt, _ := g.generate(context.Background())
+	endTime := time.Now()
+	g.DebugPrintBoard()
+
+	elapsedTime := endTime.Sub(startTime)
+	fmt.Printf("%d evolutions of board in %v\n", cnt, elapsedTime)
+
+}
+
+// Generate fills the board without any debug output
+// it returns false if the board could not be completed, or if the rules have ConnectedRoads and the roads were split
+func (g *Game) Generate() bool {
+	g.generate(context.Background())
+	return g.Complete() && !g.roadsSplit
+}
+
+// GenerateContext is Generate, stopping with the context's error if it is cancelled before the board is filled
+func (g *Game) GenerateContext(ctx context.Context) (bool, error) {
+	_, err := g.generate(ctx)
+	if err != nil {
+		return false, err
+	}
+	return g.Complete() && !g.roadsSplit, nil
+}
+
+func (g *Game) generate(ctx context.Context) (int, error) {
+	g.buildBoard = getBuildBoard(g)
+	g.pending = nil
 
 	cnt := 0
-	startTime := time.Now()
 	for i := 0; i < g.Rules.BoardHeight*g.Rules.BoardWidth; i++ {
+		if err := ctx.Err(); err != nil {
+			return cnt, err
+		}
 		cnt++
-		if !g.evolveBoard(&buildBoard) {
+		if !g.evolveBoard(&g.buildBoard) {
 			break
 		}
 	}
-	endTime := time.Now()
-	g.DebugPrintBoard()
-
-	elapsedTime := endTime.Sub(startTime)
-	fmt.Printf("%d evolutions of board in %v\n", cnt, elapsedTime)
+	return cnt, nil
+}
 
+// Complete returns true when every cell of the board has a card
+func (g *Game) Complete() bool {
+	return g.Placed() == g.playable()
 }
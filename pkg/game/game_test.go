@@ -0,0 +1,189 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_Step(t *testing.T) {
+	g := getTestGame()
+	g.Reset(1)
+	g.R = &TestRnd{}
+
+	if g.Placed() != 1 {
+		t.Fatalf("reset should leave only the seed tile, got %d placed", g.Placed())
+	}
+
+	if !g.Step(1) || g.Placed() != 2 {
+		t.Errorf("single step should place one card, got %d placed", g.Placed())
+	}
+
+	// stepping past the end stops at the same place as generating in one go
+	if g.Step(100) {
+		t.Errorf("step should report the board can't evolve any further")
+	}
+	if g.Placed() != 8 {
+		t.Errorf("got %d placed, want 8", g.Placed())
+	}
+	if g.Step(1) {
+		t.Errorf("step after the end should do nothing")
+	}
+}
+
+func Test_LockTile(t *testing.T) {
+	g := getTestGame()
+	seedTiles := g.Rules.SeedTiles
+
+	g.LockTile(0, 1, 3)
+	if !g.Locked(0, 1) || !g.Locked(1, 1) || g.Locked(0, 0) {
+		t.Errorf("locked tiles not as expected: %v", g.Rules.SeedTiles)
+	}
+
+	// locking the same cell again replaces the card
+	g.LockTile(0, 1, 4)
+	if len(g.Rules.SeedTiles) != 2 || g.Rules.SeedTiles[1] != (SeedTiles{0, 1, 4}) {
+		t.Errorf("relocking did not replace the card: %v", g.Rules.SeedTiles)
+	}
+
+	g.UnlockTile(1, 1)
+	if g.Locked(1, 1) || len(g.Rules.SeedTiles) != 1 {
+		t.Errorf("tile not unlocked: %v", g.Rules.SeedTiles)
+	}
+
+	// the rules the game was created with must not be changed underneath it
+	if len(seedTiles) != 1 || seedTiles[0] != (SeedTiles{1, 1, 2}) {
+		t.Errorf("original seed tiles were modified: %v", seedTiles)
+	}
+
+	g.Reset(1)
+	if g.Board[0][1].Card == nil || g.Board[0][1].Card.Id != 4 || g.Board[1][1].Card != nil {
+		t.Errorf("reset did not use the locked tiles")
+	}
+}
+
+func Test_CompatibleCards(t *testing.T) {
+	g := getTestGame()
+
+	// next to the cross the cell needs a road on the side facing it
+	got := g.CompatibleCards(1, 0)
+	want := []int{2, 3}
+	compareIds(t, got, want)
+
+	// the card already in a cell is ignored
+	got = g.CompatibleCards(1, 1)
+	want = []int{1, 2, 3, 4}
+	compareIds(t, got, want)
+}
+
+func compareIds(t *testing.T, got, want []int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func Test_RerollRegion(t *testing.T) {
+	t.Run("only the region is regenerated", func(t *testing.T) {
+		g := getTestGame()
+		g.Rules.SeedTiles = nil
+		g.Cards = map[int]*Card{1: g.Cards[1]}
+		for x := range g.Board {
+			for y := range g.Board[x] {
+				g.Board[x][y] = Tile{Card: g.Cards[1], X: x, Y: y}
+			}
+		}
+		g.Board[2][2] = Tile{}
+
+		err := g.RerollRegion(1, 1, 0, 0, 7)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		// with only grass to choose from the region can only be filled one way
+		for x := 0; x <= 1; x++ {
+			for y := 0; y <= 1; y++ {
+				if g.Board[x][y].Card == nil || g.Board[x][y].Card.Id != 1 {
+					t.Errorf("(%d, %d) not regenerated, got %v", x, y, g.Board[x][y].Card)
+				}
+			}
+		}
+		if g.Board[2][2].Card != nil {
+			t.Errorf("empty cell outside of the region was filled")
+		}
+	})
+
+	t.Run("an unsolvable region leaves the board unchanged", func(t *testing.T) {
+		g := getTestGame()
+		g.Rules.SeedTiles = nil
+		g.Board[1][1] = Tile{}
+
+		// the centre would need roads to the north, east and south but not the west
+		g.Board[0][1] = Tile{Card: g.Cards[2], X: 0, Y: 1}
+		g.Board[1][2] = Tile{Card: g.Cards[2], X: 1, Y: 2}
+		g.Board[2][1] = Tile{Card: g.Cards[2], X: 2, Y: 1}
+		g.Board[1][0] = Tile{Card: g.Cards[1], X: 1, Y: 0}
+		g.Board[1][1] = Tile{Card: g.Cards[4], X: 1, Y: 1}
+
+		err := g.RerollRegion(1, 1, 1, 1, 7)
+		if !errors.Is(err, ErrUnsolvable) {
+			t.Fatalf("got error %v, want %v", err, ErrUnsolvable)
+		}
+		if g.Board[1][1].Card == nil || g.Board[1][1].Card.Id != 4 {
+			t.Errorf("board was changed, got %v", g.Board[1][1].Card)
+		}
+		if g.Board[0][0].Card != nil {
+			t.Errorf("cell outside of the region was filled")
+		}
+	})
+
+	t.Run("a split from the last generation is cleared", func(t *testing.T) {
+		g := getTestGame()
+		g.Rules.SeedTiles = nil
+		g.Cards = map[int]*Card{1: g.Cards[1]}
+		g.roadsSplit = true
+
+		err := g.RerollRegion(0, 0, 2, 2, 7)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if g.RoadsSplit() {
+			t.Errorf("roads still reported as split")
+		}
+	})
+
+	t.Run("the region must be on the board", func(t *testing.T) {
+		g := getTestGame()
+		err := g.RerollRegion(0, 0, 3, 1, 7)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func Test_GenerateContext(t *testing.T) {
+	g := getTestGame()
+	g.Reset(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	complete, err := g.GenerateContext(ctx)
+	if !errors.Is(err, context.Canceled) || complete {
+		t.Errorf("got %v and %v, want a cancelled error", complete, err)
+	}
+	if g.Placed() != 1 {
+		t.Errorf("cancelled generate placed cards, got %d placed", g.Placed())
+	}
+
+	complete, err = g.GenerateContext(context.Background())
+	if err != nil || complete != g.Complete() || g.Placed() == 1 {
+		t.Errorf("got %v and %v with %d placed", complete, err, g.Placed())
+	}
+}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// hexes are flat topped and size pixels wide, so their radius is size/2 and they are sqrt(3)*size/2 tall
+const sqrt3 = 1.7320508075688772
+
+// hexCentre returns the centre of the hex at (i, j) relative to the top left of a board of size wide hexes
+func hexCentre(i, j int, size float64) (x, y float64) {
+	radius := size / 2
+	height := sqrt3 * radius
+	return radius + 1.5*radius*float64(j), height/2 + height*(float64(i)+float64(j)/2)
+}
+
+// hexBoardSize returns the width and height of a rows by columns board of size wide hexes
+func hexBoardSize(rows, columns int, size float64) (width, height float64) {
+	radius := size / 2
+	hexHeight := sqrt3 * radius
+	return 1.5*radius*float64(columns-1) + size, hexHeight*float64(rows) + hexHeight/2*float64(columns-1)
+}
+
+// hexAt returns the hex containing the point, relative to the top left of the board
+// the cell may be off the board
+func hexAt(x, y, size float64) (i, j int) {
+	radius := size / 2
+	x -= radius
+	y -= sqrt3 * radius / 2
+
+	// the fractional axial coordinates are rounded as cube coordinates, q + r + s = 0
+	q := (2.0 / 3 * x) / radius
+	r := (-1.0/3*x + sqrt3/3*y) / radius
+	s := -q - r
+
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return int(rr), int(rq)
+}
+
+// hexCorners returns the corners of the hex around the centre, clockwise from the east
+func hexCorners(x, y, radius float64) [6][2]float64 {
+	var corners [6][2]float64
+	for k := range corners {
+		angle := float64(k) * math.Pi / 3
+		corners[k] = [2]float64{x + radius*math.Cos(angle), y + radius*math.Sin(angle)}
+	}
+	return corners
+}
+
+// hexEdgeMiddle returns the middle of the side of the hex, the sides are clockwise from north
+func hexEdgeMiddle(x, y, radius float64, side int) (float64, float64) {
+	angle := float64(side)*math.Pi/3 - math.Pi/2
+	apothem := sqrt3 * radius / 2
+	return x + apothem*math.Cos(angle), y + apothem*math.Sin(angle)
+}
+
+// inHex reports whether the point, relative to the centre of the hex, is inside it
+func inHex(dx, dy, radius float64) bool {
+	dx, dy = math.Abs(dx), math.Abs(dy)
+	return dy <= sqrt3*radius/2 && sqrt3*dx+dy <= sqrt3*radius
+}
+
+// hexPixel returns the colour of the point, relative to the centre of the hex, on a card drawn
+// from its connectors, grass with a road from the centre to the middle of each side with a road connector
+func hexPixel(dx, dy, radius float64, connectors []Connector) color.RGBA {
+	road := radius / 4
+	hasRoad := false
+	for side, c := range connectors {
+		if c != Road {
+			continue
+		}
+		hasRoad = true
+		ex, ey := hexEdgeMiddle(0, 0, radius, side)
+		if distanceToSegment(dx, dy, ex, ey) <= road {
+			return roadColour
+		}
+	}
+	if hasRoad && math.Hypot(dx, dy) <= road {
+		return roadColour
+	}
+	return grassColour
+}
+
+// distanceToSegment returns the distance from the point to the line from the origin to (x, y)
+func distanceToSegment(px, py, x, y float64) float64 {
+	t := (px*x + py*y) / (x*x + y*y)
+	t = min(max(t, 0), 1)
+	return math.Hypot(px-t*x, py-t*y)
+}
+
+// renderHex draws the card into the hex centred on (cx, cy) of dst, its image rotated by the
+// card's rotation if it has one, otherwise from its connectors
+func renderHex(dst *image.RGBA, card *Card, cx, cy, size float64) {
+	radius := size / 2
+	var src image.Image
+	if card.Image != nil {
+		src = card.Image.src
+	}
+	sin, cos := math.Sincos(-float64(card.Rotation) * math.Pi / 180)
+
+	bounds := image.Rect(int(math.Floor(cx-radius)), int(math.Floor(cy-radius)), int(math.Ceil(cx+radius)), int(math.Ceil(cy+radius)))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			if !inHex(dx, dy, radius) {
+				continue
+			}
+			if src == nil {
+				dst.Set(x, y, hexPixel(dx, dy, radius, card.Connectors))
+				continue
+			}
+			// sample the image at the point rotated back to where it was before the card was rotated
+			b := src.Bounds()
+			scale := float64(b.Dx()) / size
+			sx := (dx*cos-dy*sin)*scale + float64(b.Dx())/2
+			sy := (dx*sin+dy*cos)*scale + float64(b.Dy())/2
+			dst.Set(x, y, src.At(b.Min.X+int(math.Floor(sx)), b.Min.Y+int(math.Floor(sy))))
+		}
+	}
+}
+
+var whitePixel *ebiten.Image
+
+// fillHex fills the hex, given in board pixels, on the screen
+func fillHex(screen *ebiten.Image, cx, cy, radius float64, cam ebiten.GeoM, clr color.Color) {
+	if whitePixel == nil {
+		whitePixel = ebiten.NewImage(1, 1)
+		whitePixel.Fill(color.White)
+	}
+
+	vs, is := hexVertices(cx, cy, radius)
+	r, g, b, a := clr.RGBA()
+	for k := range vs {
+		x, y := cam.Apply(float64(vs[k].DstX), float64(vs[k].DstY))
+		vs[k].DstX, vs[k].DstY = float32(x), float32(y)
+		vs[k].SrcX, vs[k].SrcY = 0, 0
+		vs[k].ColorR = float32(r) / 0xffff
+		vs[k].ColorG = float32(g) / 0xffff
+		vs[k].ColorB = float32(b) / 0xffff
+		vs[k].ColorA = float32(a) / 0xffff
+	}
+	screen.DrawTriangles(vs, is, whitePixel, &ebiten.DrawTrianglesOptions{})
+}
+
+// drawHexImage draws the card's image clipped to the hex, given in board pixels, rotated by the card's rotation
+// the same way that drawCard draws it on a square board
+func drawHexImage(screen *ebiten.Image, img *Image, cx, cy, radius float64, cam ebiten.GeoM) {
+	b := img.img.Bounds()
+	scale := float64(b.Dx()) / tileSize
+	sin, cos := math.Sincos(-img.rotateAngle)
+	vs, is := hexVertices(cx, cy, radius)
+	for k := range vs {
+		// the point on the board, rotated back to where it was on the image before the card was rotated
+		dx, dy := float64(vs[k].DstX)-cx, float64(vs[k].DstY)-cy
+		x, y := cam.Apply(float64(vs[k].DstX), float64(vs[k].DstY))
+		vs[k].DstX, vs[k].DstY = float32(x), float32(y)
+		vs[k].SrcX = float32((dx*cos-dy*sin)*scale + float64(b.Min.X) + float64(b.Dx())/2)
+		vs[k].SrcY = float32((dx*sin+dy*cos)*scale + float64(b.Min.Y) + float64(b.Dy())/2)
+		vs[k].ColorR, vs[k].ColorG, vs[k].ColorB, vs[k].ColorA = 1, 1, 1, 1
+	}
+	screen.DrawTriangles(vs, is, img.img, &ebiten.DrawTrianglesOptions{})
+}
+
+// hexVertices returns the triangles filling the hex, in board pixels, the callers move them onto the screen
+func hexVertices(cx, cy, radius float64) ([]ebiten.Vertex, []uint16) {
+	var path vector.Path
+	for k, corner := range hexCorners(cx, cy, radius) {
+		if k == 0 {
+			path.MoveTo(float32(corner[0]), float32(corner[1]))
+		} else {
+			path.LineTo(float32(corner[0]), float32(corner[1]))
+		}
+	}
+	path.Close()
+	return path.AppendVerticesAndIndicesForFilling(nil, nil)
+}
+
+// drawHexTile draws the card at (i, j) of a hex board, cards without an image are drawn from their connectors
+func (g *Game) drawHexTile(screen *ebiten.Image, tile Tile, cam ebiten.GeoM) {
+	if tile.Card == nil {
+		return
+	}
+	cx, cy := hexCentre(tile.X, tile.Y, tileSize)
+	cx, cy = cx+boardOffset, cy+boardOffset
+	radius := float64(tileSize) / 2
+	if tile.Card.Image != nil && tile.Card.Image.img != nil {
+		drawHexImage(screen, tile.Card.Image, cx, cy, radius, cam)
+		return
+	}
+
+	fillHex(screen, cx, cy, radius, cam, grassColour)
+
+	scale := float32(cam.Element(0, 0))
+	sx, sy := cam.Apply(cx, cy)
+	road := float32(radius/2) * scale
+	hasRoad := false
+	for side, c := range tile.Card.Connectors {
+		if c != Road {
+			continue
+		}
+		hasRoad = true
+		ex, ey := cam.Apply(hexEdgeMiddle(cx, cy, radius, side))
+		vector.StrokeLine(screen, float32(sx), float32(sy), float32(ex), float32(ey), road, roadColour, false)
+	}
+	if hasRoad {
+		vector.DrawFilledCircle(screen, float32(sx), float32(sy), road/2, roadColour, false)
+	}
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func getHexRules() BasicRules {
+	return BasicRules{
+		ImageSize:   32,
+		BoardWidth:  8,
+		BoardHeight: 8,
+		Topology:    Hex,
+		BaseCards: []BaseCards{
+			{Connectors: "GGGGGG", Chance: 10},
+			{Connectors: "RGGRGG", Rotations: []int{60, 120}, Chance: 10},
+			{Connectors: "RGRGGG", Rotations: []int{60, 120, 180, 240, 300}, Chance: 10},
+			{Connectors: "RGGGGG", Rotations: []int{60, 120, 180, 240, 300}, Chance: 10},
+		},
+	}
+}
+
+func Test_hexAt(t *testing.T) {
+	for i := -2; i < 5; i++ {
+		for j := -2; j < 5; j++ {
+			x, y := hexCentre(i, j, tileSize)
+			// anywhere well inside the hex is in it
+			for _, d := range [][2]float64{{0, 0}, {10, 0}, {-10, 0}, {0, 10}, {0, -10}} {
+				gi, gj := hexAt(x+d[0], y+d[1], tileSize)
+				if gi != i || gj != j {
+					t.Errorf("hexAt(%d, %d) offset %v got (%d, %d)", i, j, d, gi, gj)
+				}
+			}
+		}
+	}
+}
+
+func Test_hexNeighboursTouch(t *testing.T) {
+	// the middle of each side of a hex is the middle of the opposite side of its neighbour
+	for side, offset := range hexOffsets {
+		x, y := hexCentre(3, 3, tileSize)
+		nx, ny := hexCentre(3+offset[0], 3+offset[1], tileSize)
+		ex, ey := hexEdgeMiddle(x, y, tileSize/2, side)
+		ox, oy := hexEdgeMiddle(nx, ny, tileSize/2, Hex.opposite(side))
+		if math.Abs(ex-ox) > 1e-9 || math.Abs(ey-oy) > 1e-9 {
+			t.Errorf("side %s at (%f, %f) doesn't meet its neighbour at (%f, %f)", hexSideNames[side], ex, ey, ox, oy)
+		}
+	}
+}
+
+func Test_rotateConnectionsHex(t *testing.T) {
+	got := rotateConnections(convertConnections("RGRGGG"), 60)
+	compareConnectors(t, got, convertConnections("GRGRGG"))
+
+	got = rotateConnections(convertConnections("RGRGGG"), 300)
+	compareConnectors(t, got, convertConnections("GRGGGR"))
+}
+
+func Test_ValidateHex(t *testing.T) {
+	fs := getFS()
+	rules := getHexRules()
+	if err := rules.Validate(fs); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rules.BaseCards = append(rules.BaseCards, BaseCards{Connectors: "RRGG", Rotations: []int{90}, Chance: 1})
+	err := rules.Validate(fs)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{"connectors must be 6", "multiple of 60"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func Test_GenerateHex(t *testing.T) {
+	fs := getFS()
+	rules := getHexRules()
+	g := NewGameWithRules(fs, rules, BuildCards(rules, fs), 3)
+	g.Generate()
+
+	if g.Placed() == 0 {
+		t.Fatalf("nothing placed")
+	}
+	for i, row := range g.Board {
+		for j, tile := range row {
+			for side, offset := range hexOffsets {
+				ni, nj, ok := neighbour(len(g.Board), len(row), i, j, offset, WrapNone)
+				if !ok || tile.Card == nil || g.Board[ni][nj].Card == nil {
+					continue
+				}
+				other := g.Board[ni][nj].Card
+				if tile.Card.Connectors[side] != other.Connectors[Hex.opposite(side)] {
+					t.Errorf("card %d at (%d, %d) doesn't match card %d on side %s", tile.Card.Id, i, j, other.Id, hexSideNames[side])
+				}
+			}
+		}
+	}
+}
+
+func Test_RenderImageHex(t *testing.T) {
+	fs := getFS()
+	rules := getHexRules()
+	rules.BoardWidth, rules.BoardHeight = 2, 3
+	rules.SeedTiles = []SeedTiles{{0, 0, 1}}
+	g := NewGameWithRules(fs, rules, BuildCards(rules, fs), 1)
+
+	img := g.RenderImage()
+	width, height := hexBoardSize(2, 3, 32)
+	if img.Bounds().Dx() != int(math.Ceil(width)) || img.Bounds().Dy() != int(math.Ceil(height)) {
+		t.Errorf("image size got %v, want %fx%f", img.Bounds(), width, height)
+	}
+
+	x, y := hexCentre(0, 0, 32)
+	if got := img.RGBAAt(int(x), int(y)); got != grassColour {
+		t.Errorf("centre of the grass hex got %v, want %v", got, grassColour)
+	}
+	if got := img.RGBAAt(0, 0); got.A != 0 {
+		t.Errorf("corner outside the hexes got %v, want transparent", got)
+	}
+
+	var buf bytes.Buffer
+	err := g.WriteSVG(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "<polygon") {
+		t.Errorf("svg has no hexes:\n%s", buf.String())
+	}
+}
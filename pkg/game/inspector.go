@@ -0,0 +1,67 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// keyInspect toggles the inspector, which describes the card under the mouse
+// and the cards that are allowed next to it on each side
+const keyInspect = ebiten.KeyI
+
+var sideNames = []string{"N", "E", "S", "W"}
+
+// compatibleNeighbours returns the ids of the cards that can be placed on the side of the card
+// using the same check as getEntropyBoard, for a cell with only the card as a neighbour
+func (g *Game) compatibleNeighbours(card *Card, side int) []int {
+	connectors := append([]Connector(nil), g.emptyCell().connectors...)
+	connectors[g.Rules.Topology.opposite(side)] = card.Connectors[side]
+	return slices.DeleteFunc(g.matchingCards(connectors), func(id int) bool {
+		return !g.adjacent(card.Id, side, id)
+	})
+}
+
+// inspectText describes the card placed at (i, j)
+func (g *Game) inspectText(i, j int) string {
+	card := g.Board[i][j].Card
+	var b strings.Builder
+	fmt.Fprintf(&b, "(%d, %d) card %d\nbase %d, rotation %d\nconnectors %s", i, j, card.Id, card.Base, card.Rotation, card.ConnectorString())
+	if g.Locked(i, j) {
+		b.WriteString(", locked")
+	}
+	for side, name := range g.Rules.Topology.sideNames() {
+		fmt.Fprintf(&b, "\n%s %s: %v", name, card.Connectors[side], g.compatibleNeighbours(card, side))
+	}
+	return b.String()
+}
+
+// drawInspector outlines the card under the mouse and draws its description beside it
+func (g *Game) drawInspector(screen *ebiten.Image) {
+	if !g.inspect {
+		return
+	}
+
+	cx, cy := ebiten.CursorPosition()
+	i, j, ok := g.cellAt(cx, cy)
+	if !ok || g.Board[i][j].Card == nil {
+		return
+	}
+
+	x, y, size := g.screenRect(i, j)
+	vector.StrokeRect(screen, x, y, size, size, 2, selectedColour, false)
+
+	// the debug font is 6 by 16 pixels
+	text := g.inspectText(i, j)
+	lines := strings.Split(text, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line)*6)
+	}
+	vector.DrawFilledRect(screen, float32(cx+8), float32(cy+8), float32(width+8), float32(len(lines)*16+8), paletteColour, false)
+	ebitenutil.DebugPrintAt(screen, text, cx+12, cy+12)
+}
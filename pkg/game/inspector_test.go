@@ -0,0 +1,45 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_compatibleNeighbours(t *testing.T) {
+	g := NewGame(getFS(), 42)
+
+	for id := 1; id <= len(g.Cards); id++ {
+		card := g.Cards[id]
+		for side := 0; side < 4; side++ {
+			got := g.compatibleNeighbours(card, side)
+			for other := 1; other <= len(g.Cards); other++ {
+				want := card.Connectors[side] == g.Cards[other].Connectors[(side+2)%4]
+				if slices.Contains(got, other) != want {
+					t.Errorf("card %d %s side %s: card %d %s compatible %v, want %v",
+						id, card.ConnectorString(), sideNames[side], other, g.Cards[other].ConnectorString(), !want, want)
+				}
+			}
+		}
+	}
+}
+
+func Test_compatibleNeighboursMatchesCandidates(t *testing.T) {
+	g := NewGame(getFS(), 42)
+	g.CreateLandscape()
+
+	// a card placed between two others must be in the neighbours of both
+	for i := 1; i < g.Rules.BoardWidth-1; i++ {
+		for j := 0; j < g.Rules.BoardHeight; j++ {
+			card := g.Board[i][j].Card
+			if card == nil || g.Board[i-1][j].Card == nil || g.Board[i+1][j].Card == nil {
+				continue
+			}
+			if !slices.Contains(g.compatibleNeighbours(g.Board[i-1][j].Card, 2), card.Id) {
+				t.Errorf("card %d at (%d, %d) not compatible south of card %d", card.Id, i, j, g.Board[i-1][j].Card.Id)
+			}
+			if !slices.Contains(g.compatibleNeighbours(g.Board[i+1][j].Card, 0), card.Id) {
+				t.Errorf("card %d at (%d, %d) not compatible north of card %d", card.Id, i, j, g.Board[i+1][j].Card.Id)
+			}
+		}
+	}
+}
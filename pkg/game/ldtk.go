@@ -0,0 +1,353 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/rand"
+)
+
+const ldtkVersion = "1.5.3"
+
+// LDtk can only flip tiles, so the atlas holds each base card twice, unrotated and rotated by 90
+// the other two rotations are those tiles flipped on both axes
+const (
+	ldtkFlipNone = 0
+	ldtkFlipBoth = 3
+)
+
+const (
+	ldtkTilesetUid = 1
+	ldtkLayerUid   = 2
+	ldtkLevelUid   = 3
+)
+
+type ldtkProject struct {
+	Header             ldtkHeader  `json:"__header__"`
+	Iid                string      `json:"iid"`
+	JsonVersion        string      `json:"jsonVersion"`
+	NextUid            int         `json:"nextUid"`
+	WorldLayout        string      `json:"worldLayout"`
+	WorldGridWidth     int         `json:"worldGridWidth"`
+	WorldGridHeight    int         `json:"worldGridHeight"`
+	DefaultGridSize    int         `json:"defaultGridSize"`
+	DefaultLevelWidth  int         `json:"defaultLevelWidth"`
+	DefaultLevelHeight int         `json:"defaultLevelHeight"`
+	BgColor            string      `json:"bgColor"`
+	DefaultLevelBg     string      `json:"defaultLevelBgColor"`
+	ExternalLevels     bool        `json:"externalLevels"`
+	Defs               ldtkDefs    `json:"defs"`
+	Levels             []ldtkLevel `json:"levels"`
+	Worlds             []any       `json:"worlds"`
+	Toc                []any       `json:"toc"`
+	Flags              []string    `json:"flags"`
+}
+
+type ldtkHeader struct {
+	FileType   string `json:"fileType"`
+	App        string `json:"app"`
+	Doc        string `json:"doc"`
+	Schema     string `json:"schema"`
+	AppAuthor  string `json:"appAuthor"`
+	AppVersion string `json:"appVersion"`
+	Url        string `json:"url"`
+}
+
+type ldtkDefs struct {
+	Layers        []ldtkLayerDef   `json:"layers"`
+	Entities      []any            `json:"entities"`
+	Tilesets      []ldtkTilesetDef `json:"tilesets"`
+	Enums         []any            `json:"enums"`
+	ExternalEnums []any            `json:"externalEnums"`
+	LevelFields   []any            `json:"levelFields"`
+}
+
+type ldtkLayerDef struct {
+	Type           string  `json:"__type"`
+	Identifier     string  `json:"identifier"`
+	LayerType      string  `json:"type"`
+	Uid            int     `json:"uid"`
+	GridSize       int     `json:"gridSize"`
+	DisplayOpacity float64 `json:"displayOpacity"`
+	PxOffsetX      int     `json:"pxOffsetX"`
+	PxOffsetY      int     `json:"pxOffsetY"`
+	TilesetDefUid  int     `json:"tilesetDefUid"`
+	TilePivotX     float64 `json:"tilePivotX"`
+	TilePivotY     float64 `json:"tilePivotY"`
+	IntGridValues  []any   `json:"intGridValues"`
+	AutoRuleGroups []any   `json:"autoRuleGroups"`
+	RequiredTags   []any   `json:"requiredTags"`
+	ExcludedTags   []any   `json:"excludedTags"`
+	RenderInWorld  bool    `json:"renderInWorldView"`
+}
+
+type ldtkTilesetDef struct {
+	CWid         int    `json:"__cWid"`
+	CHei         int    `json:"__cHei"`
+	Identifier   string `json:"identifier"`
+	Uid          int    `json:"uid"`
+	RelPath      string `json:"relPath"`
+	PxWid        int    `json:"pxWid"`
+	PxHei        int    `json:"pxHei"`
+	TileGridSize int    `json:"tileGridSize"`
+	Spacing      int    `json:"spacing"`
+	Padding      int    `json:"padding"`
+	Tags         []any  `json:"tags"`
+	EnumTags     []any  `json:"enumTags"`
+	CustomData   []any  `json:"customData"`
+}
+
+type ldtkLevel struct {
+	Identifier     string              `json:"identifier"`
+	Iid            string              `json:"iid"`
+	Uid            int                 `json:"uid"`
+	WorldX         int                 `json:"worldX"`
+	WorldY         int                 `json:"worldY"`
+	WorldDepth     int                 `json:"worldDepth"`
+	PxWid          int                 `json:"pxWid"`
+	PxHei          int                 `json:"pxHei"`
+	BgColor        string              `json:"__bgColor"`
+	FieldInstances []any               `json:"fieldInstances"`
+	LayerInstances []ldtkLayerInstance `json:"layerInstances"`
+	Neighbours     []any               `json:"__neighbours"`
+}
+
+type ldtkLayerInstance struct {
+	Identifier      string         `json:"__identifier"`
+	Type            string         `json:"__type"`
+	CWid            int            `json:"__cWid"`
+	CHei            int            `json:"__cHei"`
+	GridSize        int            `json:"__gridSize"`
+	Opacity         float64        `json:"__opacity"`
+	TilesetDefUid   int            `json:"__tilesetDefUid"`
+	TilesetRelPath  string         `json:"__tilesetRelPath"`
+	Iid             string         `json:"iid"`
+	LevelId         int            `json:"levelId"`
+	LayerDefUid     int            `json:"layerDefUid"`
+	Visible         bool           `json:"visible"`
+	Seed            int            `json:"seed"`
+	IntGridCsv      []int          `json:"intGridCsv"`
+	AutoLayerTiles  []any          `json:"autoLayerTiles"`
+	GridTiles       []ldtkGridTile `json:"gridTiles"`
+	EntityInstances []any          `json:"entityInstances"`
+	OptionalRules   []any          `json:"optionalRules"`
+}
+
+type ldtkGridTile struct {
+	Px  [2]int  `json:"px"`
+	Src [2]int  `json:"src"`
+	F   int     `json:"f"`
+	T   int     `json:"t"`
+	D   []int   `json:"d"`
+	A   float64 `json:"a"`
+}
+
+// ExportLDtk writes the board to dir as a <name>.ldtk project with a single level,
+// along with a <name>.png atlas that the project uses as its tileset
+func (g *Game) ExportLDtk(dir, name string) error {
+	if g.Rules.Topology != Square {
+		return fmt.Errorf("can't export a %s board to LDtk", g.Rules.Topology)
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	atlas, err := os.Create(filepath.Join(dir, name+".png"))
+	if err != nil {
+		return err
+	}
+	defer atlas.Close()
+	err = g.WriteLDtkAtlas(atlas)
+	if err != nil {
+		return err
+	}
+
+	project, err := os.Create(filepath.Join(dir, name+".ldtk"))
+	if err != nil {
+		return err
+	}
+	defer project.Close()
+	return g.WriteLDtk(project, name+".png")
+}
+
+// WriteLDtkAtlas writes the tileset image used by the LDtk project as a png
+// each base card gets two columns, the image unrotated followed by the image rotated by 90
+func (g *Game) WriteLDtkAtlas(w io.Writer) error {
+	size := g.Rules.ImageSize
+	atlas := image.NewRGBA(image.Rect(0, 0, 2*len(g.Rules.BaseCards)*size, size))
+
+	for base := range g.Rules.BaseCards {
+		src := g.baseImage(base)
+		if src == nil {
+			continue
+		}
+		x := 2 * base * size
+		draw.Draw(atlas, image.Rect(x, 0, x+size, size), src, src.Bounds().Min, draw.Src)
+		draw.Draw(atlas, image.Rect(x+size, 0, x+2*size, size), rotateImage(src, 90), image.Point{}, draw.Src)
+	}
+
+	return png.Encode(w, atlas)
+}
+
+// WriteLDtk writes an LDtk project containing one level with the board as a tile layer
+// atlasPath is the location of the atlas written by WriteLDtkAtlas relative to the project
+func (g *Game) WriteLDtk(w io.Writer, atlasPath string) error {
+	size := g.Rules.ImageSize
+	rows := len(g.Board)
+	columns := 0
+	if rows > 0 {
+		columns = len(g.Board[0])
+	}
+	atlasColumns := 2 * len(g.Rules.BaseCards)
+
+	// iids only need to be unique, so derive them from the seed to keep exports reproducible
+	r := NewSeed(g.Seed)
+
+	tiles := []ldtkGridTile{}
+	for i, row := range g.Board {
+		for j, tile := range row {
+			t, f, ok := g.ldtkTile(tile.Card)
+			if !ok {
+				continue
+			}
+			tiles = append(tiles, ldtkGridTile{
+				Px:  [2]int{j * size, i * size},
+				Src: [2]int{(t % atlasColumns) * size, (t / atlasColumns) * size},
+				F:   f,
+				T:   t,
+				D:   []int{j + i*columns},
+				A:   1,
+			})
+		}
+	}
+
+	project := ldtkProject{
+		Header: ldtkHeader{
+			FileType:   "LDtk Project JSON",
+			App:        "LDtk",
+			Doc:        "https://ldtk.io/json",
+			Schema:     "https://ldtk.io/files/JSON_SCHEMA.json",
+			AppAuthor:  "Sebastien 'deepnight' Benard",
+			AppVersion: ldtkVersion,
+			Url:        "https://ldtk.io",
+		},
+		Iid:                ldtkIid(r),
+		JsonVersion:        ldtkVersion,
+		NextUid:            ldtkLevelUid + 1,
+		WorldLayout:        "Free",
+		WorldGridWidth:     columns * size,
+		WorldGridHeight:    rows * size,
+		DefaultGridSize:    size,
+		DefaultLevelWidth:  columns * size,
+		DefaultLevelHeight: rows * size,
+		BgColor:            "#40465B",
+		DefaultLevelBg:     "#696A79",
+		Defs: ldtkDefs{
+			Layers: []ldtkLayerDef{{
+				Type:           "Tiles",
+				Identifier:     "Board",
+				LayerType:      "Tiles",
+				Uid:            ldtkLayerUid,
+				GridSize:       size,
+				DisplayOpacity: 1,
+				TilesetDefUid:  ldtkTilesetUid,
+				IntGridValues:  []any{},
+				AutoRuleGroups: []any{},
+				RequiredTags:   []any{},
+				ExcludedTags:   []any{},
+				RenderInWorld:  true,
+			}},
+			Entities: []any{},
+			Tilesets: []ldtkTilesetDef{{
+				CWid:         atlasColumns,
+				CHei:         1,
+				Identifier:   "Wfc",
+				Uid:          ldtkTilesetUid,
+				RelPath:      atlasPath,
+				PxWid:        atlasColumns * size,
+				PxHei:        size,
+				TileGridSize: size,
+				Tags:         []any{},
+				EnumTags:     []any{},
+				CustomData:   []any{},
+			}},
+			Enums:         []any{},
+			ExternalEnums: []any{},
+			LevelFields:   []any{},
+		},
+		Levels: []ldtkLevel{{
+			Identifier:     "Level_0",
+			Iid:            ldtkIid(r),
+			Uid:            ldtkLevelUid,
+			PxWid:          columns * size,
+			PxHei:          rows * size,
+			BgColor:        "#696A79",
+			FieldInstances: []any{},
+			LayerInstances: []ldtkLayerInstance{{
+				Identifier:      "Board",
+				Type:            "Tiles",
+				CWid:            columns,
+				CHei:            rows,
+				GridSize:        size,
+				Opacity:         1,
+				TilesetDefUid:   ldtkTilesetUid,
+				TilesetRelPath:  atlasPath,
+				Iid:             ldtkIid(r),
+				LevelId:         ldtkLevelUid,
+				LayerDefUid:     ldtkLayerUid,
+				Visible:         true,
+				Seed:            int(g.Seed % 10000000), // auto layer seed, kept small for json readers
+				IntGridCsv:      []int{},
+				AutoLayerTiles:  []any{},
+				GridTiles:       tiles,
+				EntityInstances: []any{},
+				OptionalRules:   []any{},
+			}},
+			Neighbours: []any{},
+		}},
+		Worlds: []any{},
+		Toc:    []any{},
+		Flags:  []string{},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(project)
+}
+
+// ldtkTile returns the atlas tile id and flip bits for the card
+// ok is false for empty cells and cards without an image
+func (g *Game) ldtkTile(card *Card) (tileId, flip int, ok bool) {
+	if card == nil || card.Base >= len(g.Rules.BaseCards) || g.Rules.BaseCards[card.Base].Filename == "" {
+		return 0, 0, false
+	}
+
+	tileId = 2 * card.Base
+	switch card.Rotation {
+	case 90:
+		return tileId + 1, ldtkFlipNone, true
+	case 180:
+		return tileId, ldtkFlipBoth, true
+	case 270:
+		return tileId + 1, ldtkFlipBoth, true
+	}
+	return tileId, ldtkFlipNone, true
+}
+
+// ldtkIid formats 16 random bytes as a version 4 uuid
+func ldtkIid(r *rand.Rand) string {
+	b := make([]byte, 16)
+	for i := range b {
+		b[i] = byte(r.Intn(256))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
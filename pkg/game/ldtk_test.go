@@ -0,0 +1,109 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func Test_ldtkTile(t *testing.T) {
+	g := getTestGame()
+	g.Rules.BaseCards = []BaseCards{
+		{Filename: "grass.png", Connectors: "GGGG"},
+		{Filename: "corner.png", Connectors: "RRGG", Rotations: []int{90, 180, 270}},
+		{Filename: "", Connectors: "RRRR"},
+	}
+
+	tests := []struct {
+		name     string
+		card     *Card
+		wantTile int
+		wantFlip int
+		wantOk   bool
+	}{
+		{"empty cell", nil, 0, 0, false},
+		{"no rotation", &Card{Base: 1}, 2, ldtkFlipNone, true},
+		{"rotate 90", &Card{Base: 1, Rotation: 90}, 3, ldtkFlipNone, true},
+		{"rotate 180", &Card{Base: 1, Rotation: 180}, 2, ldtkFlipBoth, true},
+		{"rotate 270", &Card{Base: 1, Rotation: 270}, 3, ldtkFlipBoth, true},
+		{"no image", &Card{Base: 2}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tile, flip, ok := g.ldtkTile(tt.card)
+			if tile != tt.wantTile || flip != tt.wantFlip || ok != tt.wantOk {
+				t.Errorf("got (%d, %d, %v), want (%d, %d, %v)", tile, flip, ok, tt.wantTile, tt.wantFlip, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_WriteLDtk(t *testing.T) {
+	g := getTestGame()
+	g.Rules.ImageSize = 32
+	g.Rules.BaseCards = []BaseCards{
+		{Filename: "grass.png", Connectors: "GGGG"},
+		{Filename: "corner.png", Connectors: "RRGG", Rotations: []int{90, 180, 270}},
+	}
+	g.Board[1][1].Card = &Card{Id: 4, Base: 1, Rotation: 270}
+
+	var buf bytes.Buffer
+	err := g.WriteLDtk(&buf, "wfc.png")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var got ldtkProject
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("project is not valid json: %v", err)
+	}
+
+	if got.Defs.Tilesets[0].RelPath != "wfc.png" || got.Defs.Tilesets[0].CWid != 4 {
+		t.Errorf("tileset not defined correctly: %+v", got.Defs.Tilesets[0])
+	}
+
+	tiles := got.Levels[0].LayerInstances[0].GridTiles
+	if len(tiles) != 1 {
+		t.Fatalf("expected a single tile, got %v", tiles)
+	}
+	want := ldtkGridTile{Px: [2]int{32, 32}, Src: [2]int{96, 0}, F: ldtkFlipBoth, T: 3, D: []int{4}, A: 1}
+	if tiles[0].Px != want.Px || tiles[0].Src != want.Src || tiles[0].F != want.F || tiles[0].T != want.T || tiles[0].D[0] != want.D[0] {
+		t.Errorf("got %+v, want %+v", tiles[0], want)
+	}
+}
+
+func Test_rotateImage(t *testing.T) {
+	// a 2x1 image with a red pixel on the left and a blue one on the right
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		rotation int
+		width    int
+		height   int
+		redAt    image.Point
+		blueAt   image.Point
+	}{
+		{0, 2, 1, image.Pt(0, 0), image.Pt(1, 0)},
+		{90, 1, 2, image.Pt(0, 0), image.Pt(0, 1)},
+		{180, 2, 1, image.Pt(1, 0), image.Pt(0, 0)},
+		{270, 1, 2, image.Pt(0, 1), image.Pt(0, 0)},
+	}
+
+	for _, tt := range tests {
+		got := rotateImage(src, tt.rotation)
+		if got.Bounds().Dx() != tt.width || got.Bounds().Dy() != tt.height {
+			t.Errorf("rotate %d: got size %v", tt.rotation, got.Bounds())
+		}
+		if got.RGBAAt(tt.redAt.X, tt.redAt.Y) != red || got.RGBAAt(tt.blueAt.X, tt.blueAt.Y) != blue {
+			t.Errorf("rotate %d: pixels not rotated correctly", tt.rotation)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+)
+
+// mask characters, a mask has a row of characters for each row of the board
+const (
+	maskInside  = '.'
+	maskOutside = '#'
+)
+
+// LoadMask returns which cells of the board are outside the playable area, from either the Mask
+// or the MaskFile of the rules, nil if the rules don't have a mask
+// in a mask file each pixel is a cell, black or transparent pixels are outside
+func (r BasicRules) LoadMask(fsys fs.FS) ([][]bool, error) {
+	switch {
+	case len(r.Mask) > 0 && r.MaskFile != "":
+		return nil, errors.New("give either a mask or a maskFile, not both")
+	case len(r.Mask) > 0:
+		return parseMask(r.Mask, r.BoardWidth, r.BoardHeight)
+	case r.MaskFile != "":
+		f, err := fsys.Open(r.MaskFile)
+		if err != nil {
+			return nil, fmt.Errorf("mask: %w", err)
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("mask %s: %w", r.MaskFile, err)
+		}
+		return imageMask(img, r.BoardWidth, r.BoardHeight)
+	}
+	return nil, nil
+}
+
+func parseMask(rows []string, boardWidth, boardHeight int) ([][]bool, error) {
+	if len(rows) != boardWidth {
+		return nil, fmt.Errorf("mask has %d rows, the board has %d", len(rows), boardWidth)
+	}
+	mask := make([][]bool, len(rows))
+	for i, row := range rows {
+		if len(row) != boardHeight {
+			return nil, fmt.Errorf("mask row %d has %d cells, the board has %d", i, len(row), boardHeight)
+		}
+		mask[i] = make([]bool, len(row))
+		for j, c := range []byte(row) {
+			switch c {
+			case maskInside:
+			case maskOutside:
+				mask[i][j] = true
+			default:
+				return nil, fmt.Errorf("mask row %d: %q isn't %q or %q", i, c, maskInside, maskOutside)
+			}
+		}
+	}
+	return mask, nil
+}
+
+func imageMask(img image.Image, boardWidth, boardHeight int) ([][]bool, error) {
+	b := img.Bounds()
+	if b.Dy() != boardWidth || b.Dx() != boardHeight {
+		return nil, fmt.Errorf("mask image is %dx%d, it needs a pixel for each cell of the %dx%d board", b.Dx(), b.Dy(), boardHeight, boardWidth)
+	}
+	mask := make([][]bool, boardWidth)
+	for i := range mask {
+		mask[i] = make([]bool, boardHeight)
+		for j := range mask[i] {
+			r, g, bl, a := img.At(b.Min.X+j, b.Min.Y+i).RGBA()
+			mask[i][j] = a < 0x8000 || (r+g+bl)/3 < 0x8000
+		}
+	}
+	return mask, nil
+}
+
+// loadMask reads the mask from the rules, they should already be validated
+func (g *Game) loadMask() {
+	mask, err := g.Rules.LoadMask(g.Fs)
+	if err != nil {
+		fmt.Println("error loading mask:", err)
+		panic(err)
+	}
+	g.mask = mask
+}
+
+// Masked returns true if (x, y) is outside the playable area of the board
+func (g *Game) Masked(x, y int) bool {
+	return g.mask != nil && g.mask[x][y]
+}
+
+// maskedCell is the build cell for a cell outside the board, every side has the mask connector
+func (g *Game) maskedCell() buildCell {
+	connector := fullConnector
+	switch g.Rules.MaskConnector {
+	case "G":
+		connector = Grass
+	case "R":
+		connector = Road
+	}
+	connectors := make([]Connector, g.Rules.Topology.Sides())
+	for k := range connectors {
+		connectors[k] = connector
+	}
+	return buildCell{placed: true, connectors: connectors}
+}
+
+// playable returns the number of cells inside the mask
+func (g *Game) playable() int {
+	cells := g.Rules.BoardWidth * g.Rules.BoardHeight
+	for _, row := range g.mask {
+		for _, masked := range row {
+			if masked {
+				cells--
+			}
+		}
+	}
+	return cells
+}
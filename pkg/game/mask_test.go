@@ -0,0 +1,145 @@
+package game
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func getMaskedGame(t *testing.T, fsys fstest.MapFS, mask []string, maskFile string) *Game {
+	t.Helper()
+	rules := LoadRules("static/rules/basicRules.json", fsys)
+	rules.BoardWidth, rules.BoardHeight = 6, 8
+	rules.SeedTiles = nil
+	rules.Mask = mask
+	rules.MaskFile = maskFile
+	rules.MaskConnector = "G"
+	err := rules.Validate(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return NewGameWithRules(fsys, rules, BuildCards(rules, fsys), 7)
+}
+
+// holeMask is a 6x8 board with a 2x3 hole in the middle and the top left corner cut off
+var holeMask = []string{
+	"##......",
+	"#.......",
+	"..###...",
+	"..###...",
+	"........",
+	"........",
+}
+
+func checkMaskedBoard(t *testing.T, g *Game) {
+	t.Helper()
+	if !g.Generate() {
+		t.Fatalf("board didn't complete, %d placed of %d playable", g.Placed(), g.playable())
+	}
+	if g.playable() != 6*8-9 {
+		t.Errorf("playable got %d, want %d", g.playable(), 6*8-9)
+	}
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if g.Masked(i, j) != (holeMask[i][j] == '#') {
+				t.Fatalf("(%d, %d) masked is %v", i, j, g.Masked(i, j))
+			}
+			if g.Masked(i, j) {
+				if tile.Card != nil {
+					t.Errorf("masked cell (%d, %d) has card %d", i, j, tile.Card.Id)
+				}
+				continue
+			}
+			// every side facing a masked cell must be the mask connector
+			for side, offset := range sideOffsets {
+				ni, nj, ok := neighbour(6, 8, i, j, offset, WrapNone)
+				if ok && g.Masked(ni, nj) && tile.Card.Connectors[side] != Grass {
+					t.Errorf("card %d at (%d, %d) has %s facing the mask", tile.Card.Id, i, j, tile.Card.Connectors[side])
+				}
+			}
+		}
+	}
+}
+
+func Test_MaskGrid(t *testing.T) {
+	g := getMaskedGame(t, getFS().(fstest.MapFS), holeMask, "")
+	checkMaskedBoard(t, g)
+	if !strings.HasPrefix(g.progress(), "39/39 placed") {
+		t.Errorf("progress got %q, want 39/39 placed", g.progress())
+	}
+
+	var b strings.Builder
+	if err := g.WriteTerminal(&b, false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if lines := strings.Split(b.String(), "\n"); !strings.HasPrefix(lines[0], "  ") || string([]rune(lines[2])[2:5]) != "   " {
+		t.Errorf("masked cells aren't blank in the terminal:\n%s", b.String())
+	}
+}
+
+func Test_MaskFile(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	for i, row := range holeMask {
+		for j, c := range row {
+			if c == '.' {
+				img.Set(j, i, color.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	fsys := getFS().(fstest.MapFS)
+	fsys["static/masks/hole.png"] = &fstest.MapFile{Data: buf.Bytes()}
+
+	checkMaskedBoard(t, getMaskedGame(t, fsys, nil, "static/masks/hole.png"))
+}
+
+func Test_MaskReroll(t *testing.T) {
+	g := getMaskedGame(t, getFS().(fstest.MapFS), holeMask, "")
+	if !g.Generate() {
+		t.Fatalf("board didn't complete")
+	}
+
+	// the region covers the corner and the hole, which never get cards
+	err := g.RerollRegion(0, 0, 3, 4, 5)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	checkMaskedBoard(t, g)
+}
+
+func Test_ValidateMask(t *testing.T) {
+	fsys := getFS()
+	tests := []struct {
+		name   string
+		change func(r *BasicRules)
+		want   string
+	}{
+		{"too few rows", func(r *BasicRules) { r.Mask = holeMask[:5] }, "mask has 5 rows"},
+		{"short row", func(r *BasicRules) { r.Mask = append([]string{"......."}, holeMask[1:]...) }, "mask row 0 has 7 cells"},
+		{"bad character", func(r *BasicRules) { r.Mask = append([]string{"...x...."}, holeMask[1:]...) }, `'x'`},
+		{"both masks", func(r *BasicRules) { r.MaskFile = "mask.png" }, "not both"},
+		{"missing file", func(r *BasicRules) { r.Mask, r.MaskFile = nil, "mask.png" }, "mask:"},
+		{"seed outside", func(r *BasicRules) { r.SeedTiles = []SeedTiles{{2, 3, 1}} }, "outside the mask"},
+		{"connector", func(r *BasicRules) { r.MaskConnector = "X" }, "maskConnector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := LoadRules("static/rules/basicRules.json", fsys)
+			rules.BoardWidth, rules.BoardHeight = 6, 8
+			rules.SeedTiles = nil
+			rules.Mask = holeMask
+			tt.change(&rules)
+			err := rules.Validate(fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
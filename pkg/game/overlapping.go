@@ -0,0 +1,302 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// OverlappingAttempts is how many seeds Overlapping.Generate is tried with before giving up
+const OverlappingAttempts = 10
+
+// overlapOffsets are the steps to the neighbouring pattern on each side, as x and y, north, east, south, west
+var overlapOffsets = [4][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// Overlapping is the overlapping model, rather than tiles with connectors it learns the NxN patterns of pixels
+// in a sample image and how often they appear, then generates an image where every NxN area is one of them
+// patterns are next to each other when they overlap, shifted by a pixel, with the same colours
+type Overlapping struct {
+	N        int
+	Patterns [][]int // the colours of each pattern, a row at a time, as indexes into the palette
+	Weights  []int   // how many times each pattern appears in the sample
+	Palette  []color.Color
+
+	// propagator lists, for each side and pattern, the patterns that can be next to it on that side
+	propagator [4][][]int
+}
+
+// LearnPatterns extracts the NxN patterns from the sample
+// symmetry is how many of the rotations and reflections of each pattern are added, from 1 for only the
+// pattern as it is to 8 for all of them, periodic treats the sample as wrapping around at its edges
+func LearnPatterns(sample image.Image, n, symmetry int, periodic bool) (*Overlapping, error) {
+	b := sample.Bounds()
+	switch {
+	case n < 2:
+		return nil, fmt.Errorf("patterns must be at least 2x2, got %d", n)
+	case symmetry < 1 || symmetry > 8:
+		return nil, fmt.Errorf("symmetry must be from 1 to 8, got %d", symmetry)
+	case b.Dx() < n || b.Dy() < n:
+		return nil, fmt.Errorf("the %dx%d sample is smaller than the %dx%d patterns", b.Dx(), b.Dy(), n, n)
+	}
+
+	o := &Overlapping{N: n}
+	sampleColours := make([][]int, b.Dy())
+	for y := range sampleColours {
+		sampleColours[y] = make([]int, b.Dx())
+		for x := range sampleColours[y] {
+			c := color.NRGBAModel.Convert(sample.At(b.Min.X+x, b.Min.Y+y))
+			sampleColours[y][x] = o.colourIndex(c)
+		}
+	}
+
+	width, height := b.Dx(), b.Dy()
+	if !periodic {
+		width, height = width-n+1, height-n+1
+	}
+	index := map[string]int{}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := make([]int, n*n)
+			for dy := 0; dy < n; dy++ {
+				for dx := 0; dx < n; dx++ {
+					p[dy*n+dx] = sampleColours[(y+dy)%b.Dy()][(x+dx)%b.Dx()]
+				}
+			}
+			for _, variant := range patternVariants(p, n)[:symmetry] {
+				key := fmt.Sprint(variant)
+				if k, ok := index[key]; ok {
+					o.Weights[k]++
+					continue
+				}
+				index[key] = len(o.Patterns)
+				o.Patterns = append(o.Patterns, variant)
+				o.Weights = append(o.Weights, 1)
+			}
+		}
+	}
+
+	for side, offset := range overlapOffsets {
+		o.propagator[side] = make([][]int, len(o.Patterns))
+		for p := range o.Patterns {
+			for q := range o.Patterns {
+				if o.agrees(p, q, offset[0], offset[1]) {
+					o.propagator[side][p] = append(o.propagator[side][p], q)
+				}
+			}
+		}
+	}
+	return o, nil
+}
+
+// colourIndex returns the colour's index in the palette, adding it if it's new
+func (o *Overlapping) colourIndex(c color.Color) int {
+	for k, p := range o.Palette {
+		if p == c {
+			return k
+		}
+	}
+	o.Palette = append(o.Palette, c)
+	return len(o.Palette) - 1
+}
+
+// patternVariants returns the pattern, its reflection, and the rotations and reflections of those
+// in the order that symmetry takes them
+func patternVariants(p []int, n int) [][]int {
+	variants := make([][]int, 8)
+	variants[0] = p
+	for k := 0; k < 8; k += 2 {
+		if k > 0 {
+			variants[k] = rotatePattern(variants[k-2], n)
+		}
+		variants[k+1] = reflectPattern(variants[k], n)
+	}
+	return variants
+}
+
+// rotatePattern turns the pattern a quarter clockwise
+func rotatePattern(p []int, n int) []int {
+	r := make([]int, len(p))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			r[y*n+x] = p[(n-1-x)*n+y]
+		}
+	}
+	return r
+}
+
+// reflectPattern mirrors the pattern left to right
+func reflectPattern(p []int, n int) []int {
+	r := make([]int, len(p))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			r[y*n+x] = p[y*n+n-1-x]
+		}
+	}
+	return r
+}
+
+// agrees reports whether pattern q can be at (dx, dy) from pattern p, where they overlap the colours must be the same
+func (o *Overlapping) agrees(p, q, dx, dy int) bool {
+	n := o.N
+	for y := max(0, dy); y < min(n, n+dy); y++ {
+		for x := max(0, dx); x < min(n, n+dx); x++ {
+			if o.Patterns[p][y*n+x] != o.Patterns[q][(y-dy)*n+x-dx] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// overlapWave is the patterns still possible at each position of the output, a position for each
+// NxN area, so a width by height output has (width-N+1) by (height-N+1) positions
+type overlapWave struct {
+	columns, rows int
+	possible      [][]bool
+	counts        []int
+}
+
+// Generate makes a width by height image from the patterns, trying OverlappingAttempts seeds
+// it returns false if every attempt ran into a position where no pattern fits
+func (o *Overlapping) Generate(width, height int, seed uint64) (*image.NRGBA, bool) {
+	if width < o.N || height < o.N {
+		return nil, false
+	}
+	for attempt := 0; attempt < OverlappingAttempts; attempt++ {
+		wave, err := o.run(width-o.N+1, height-o.N+1, NewSeed(mix(seed, attempt)))
+		if err == nil {
+			return o.render(wave, width, height), true
+		}
+	}
+	return nil, false
+}
+
+var errContradiction = errors.New("no pattern fits")
+
+// run collapses the wave, a position with the fewest patterns left at a time
+func (o *Overlapping) run(columns, rows int, r Rnd) (*overlapWave, error) {
+	wave := &overlapWave{columns: columns, rows: rows, possible: make([][]bool, columns*rows), counts: make([]int, columns*rows)}
+	for k := range wave.possible {
+		wave.possible[k] = make([]bool, len(o.Patterns))
+		for p := range wave.possible[k] {
+			wave.possible[k][p] = true
+		}
+		wave.counts[k] = len(o.Patterns)
+	}
+
+	for {
+		var lowest []int
+		for k, count := range wave.counts {
+			if count == 0 {
+				return nil, errContradiction
+			}
+			if count == 1 {
+				continue
+			}
+			if len(lowest) == 0 || count < wave.counts[lowest[0]] {
+				lowest = lowest[:0]
+			}
+			if len(lowest) == 0 || count == wave.counts[lowest[0]] {
+				lowest = append(lowest, k)
+			}
+		}
+		if len(lowest) == 0 {
+			return wave, nil
+		}
+
+		k := lowest[r.Intn(len(lowest))]
+		chosen := o.pick(r, wave.possible[k])
+		for p := range wave.possible[k] {
+			wave.possible[k][p] = p == chosen
+		}
+		wave.counts[k] = 1
+		if !o.propagate(wave, k) {
+			return nil, errContradiction
+		}
+	}
+}
+
+// pick chooses one of the possible patterns at random, weighted by how often it appears in the sample
+func (o *Overlapping) pick(r Rnd, possible []bool) int {
+	total := 0
+	for p, ok := range possible {
+		if ok {
+			total += o.Weights[p]
+		}
+	}
+	n := r.Intn(total)
+	for p, ok := range possible {
+		if !ok {
+			continue
+		}
+		n -= o.Weights[p]
+		if n < 0 {
+			return p
+		}
+	}
+	return -1
+}
+
+// propagate removes the patterns that no longer fit next to the changed position, and then next to
+// the positions those changed, returning false if a position is left without a pattern
+func (o *Overlapping) propagate(wave *overlapWave, start int) bool {
+	stack := []int{start}
+	for len(stack) > 0 {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := k%wave.columns, k/wave.columns
+
+		for side, offset := range overlapOffsets {
+			nx, ny := x+offset[0], y+offset[1]
+			if nx < 0 || nx >= wave.columns || ny < 0 || ny >= wave.rows {
+				continue
+			}
+			nk := ny*wave.columns + nx
+
+			allowed := make([]bool, len(o.Patterns))
+			for p, ok := range wave.possible[k] {
+				if !ok {
+					continue
+				}
+				for _, q := range o.propagator[side][p] {
+					allowed[q] = true
+				}
+			}
+
+			changed := false
+			for q, ok := range wave.possible[nk] {
+				if ok && !allowed[q] {
+					wave.possible[nk][q] = false
+					wave.counts[nk]--
+					changed = true
+				}
+			}
+			if wave.counts[nk] == 0 {
+				return false
+			}
+			if changed {
+				stack = append(stack, nk)
+			}
+		}
+	}
+	return true
+}
+
+// render draws the collapsed wave, each pixel comes from the pattern at the nearest position covering it
+func (o *Overlapping) render(wave *overlapWave, width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px, py := min(x, wave.columns-1), min(y, wave.rows-1)
+			k := py*wave.columns + px
+			for p, ok := range wave.possible[k] {
+				if ok {
+					img.Set(x, y, o.Palette[o.Patterns[p][(y-py)*o.N+x-px]])
+					break
+				}
+			}
+		}
+	}
+	return img
+}
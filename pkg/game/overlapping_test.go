@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// stripesSample is vertical stripes, two columns of black then one of white
+func stripesSample() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 6, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			c := color.Black
+			if x%3 == 2 {
+				c = color.White
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func Test_LearnPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		symmetry int
+		periodic bool
+		patterns int
+	}{
+		// the three 2x2 windows of the stripes, wrapping or not
+		{"periodic", 1, true, 3},
+		{"not periodic", 1, false, 3},
+		// rotating turns the two striped windows horizontal, the all black one stays the same
+		{"rotations", 8, true, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := LearnPatterns(stripesSample(), 2, tt.symmetry, tt.periodic)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(o.Patterns) != tt.patterns {
+				t.Errorf("got %d patterns, want %d", len(o.Patterns), tt.patterns)
+			}
+			total := 0
+			for _, w := range o.Weights {
+				total += w
+			}
+			windows := 36
+			if !tt.periodic {
+				windows = 25
+			}
+			if total != windows*tt.symmetry {
+				t.Errorf("weights add up to %d, want %d", total, windows*tt.symmetry)
+			}
+		})
+	}
+
+	if _, err := LearnPatterns(stripesSample(), 7, 1, false); err == nil {
+		t.Errorf("expected an error for patterns bigger than the sample")
+	}
+	if _, err := LearnPatterns(stripesSample(), 2, 9, false); err == nil {
+		t.Errorf("expected an error for symmetry 9")
+	}
+}
+
+func Test_patternVariants(t *testing.T) {
+	// 0 1
+	// 2 3
+	variants := patternVariants([]int{0, 1, 2, 3}, 2)
+	want := [8][]int{
+		{0, 1, 2, 3}, {1, 0, 3, 2},
+		{2, 0, 3, 1}, {0, 2, 1, 3},
+		{3, 2, 1, 0}, {2, 3, 0, 1},
+		{1, 3, 0, 2}, {3, 1, 2, 0},
+	}
+	for k := range want {
+		if fmt.Sprint(variants[k]) != fmt.Sprint(want[k]) {
+			t.Errorf("variant %d got %v, want %v", k, variants[k], want[k])
+		}
+	}
+}
+
+func Test_OverlappingGenerate(t *testing.T) {
+	o, err := LearnPatterns(stripesSample(), 3, 1, true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	img, ok := o.Generate(20, 12, 3)
+	if !ok {
+		t.Fatalf("generate failed")
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 12 {
+		t.Fatalf("image is %v, want 20x12", img.Bounds())
+	}
+
+	// every 3x3 area of the output must be one of the sample's patterns
+	known := map[string]bool{}
+	for _, p := range o.Patterns {
+		known[fmt.Sprint(p)] = true
+	}
+	for y := 0; y+3 <= 12; y++ {
+		for x := 0; x+3 <= 20; x++ {
+			p := make([]int, 9)
+			for dy := 0; dy < 3; dy++ {
+				for dx := 0; dx < 3; dx++ {
+					p[dy*3+dx] = o.colourIndex(img.At(x+dx, y+dy))
+				}
+			}
+			if !known[fmt.Sprint(p)] {
+				t.Fatalf("the area at (%d, %d) %v isn't a pattern from the sample", x, y, p)
+			}
+		}
+	}
+
+	again, _ := o.Generate(20, 12, 3)
+	if fmt.Sprint(again.Pix) != fmt.Sprint(img.Pix) {
+		t.Errorf("the same seed generated a different image")
+	}
+}
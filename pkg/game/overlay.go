@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type overlayMode int
+
+const (
+	overlayOff     overlayMode = iota
+	overlayCount               // shade cells by the number of cards that could be placed
+	overlayEntropy             // shade cells by the weighted entropy of the cards that could be placed
+)
+
+func (o overlayMode) String() string {
+	switch o {
+	case overlayCount:
+		return "count"
+	case overlayEntropy:
+		return "entropy"
+	}
+	return "off"
+}
+
+var (
+	contradictionColour = color.RGBA{0, 0, 0, 200}
+	nextChoiceColour    = color.RGBA{255, 255, 0, 255}
+)
+
+// drawOverlay shades the uncollapsed cells from red, about to collapse, to blue, anything goes
+// and outlines the cell that the next evolveBoard call will place a card in
+func (g *Game) drawOverlay(screen *ebiten.Image) {
+	if g.overlay == overlayOff {
+		return
+	}
+
+	buildBoard := g.currentBuildBoard()
+	entropyBoard := getEntropyBoard(buildBoard, g)
+
+	all := make([]int, 0, len(g.Cards))
+	for id := range g.Cards {
+		all = append(all, id)
+	}
+	maxValue := g.overlayValue(all)
+
+	for i, row := range entropyBoard {
+		for j, ids := range row {
+			if buildBoard[i][j].placed || !g.onScreen(i, j) {
+				continue
+			}
+			x, y, size := g.screenRect(i, j)
+			clr := contradictionColour
+			switch {
+			case len(ids) > 0 && maxValue > 0:
+				clr = heatColour(g.overlayValue(ids) / maxValue)
+			case len(ids) > 0:
+				// with a single card, or weights with no entropy, every cell is as close to collapsing
+				clr = heatColour(0)
+			}
+			vector.DrawFilledRect(screen, x, y, size, size, clr, false)
+		}
+	}
+
+	next, ok := g.nextChoice(buildBoard)
+	if ok {
+		x, y, size := g.screenRect(next.x, next.y)
+		vector.StrokeRect(screen, x+1, y+1, size-2, size-2, 2, nextChoiceColour, false)
+	}
+
+	cx, cy := ebiten.CursorPosition()
+	i, j, ok := g.cellAt(cx, cy)
+	if ok && !buildBoard[i][j].placed {
+		text := fmt.Sprintf("%d cards", len(entropyBoard[i][j]))
+		if g.overlay == overlayEntropy {
+			text = fmt.Sprintf("entropy %.2f", g.overlayValue(entropyBoard[i][j]))
+		}
+		ebitenutil.DebugPrintAt(screen, text, cx+12, cy+12)
+	}
+}
+
+// superpositionOpacity is how solid an undecided cell is drawn, so it can be told apart from placed cards
+const superpositionOpacity = 0.6
+
+// drawSuperposition draws every undecided cell as a blend of the cards that could still be placed there
+// each card is weighted by its chance, the same way the randomiser will pick between them
+func (g *Game) drawSuperposition(screen *ebiten.Image) {
+	if !g.superposition {
+		return
+	}
+
+	buildBoard := g.currentBuildBoard()
+	entropyBoard := getEntropyBoard(buildBoard, g)
+	cam := g.cam.geoM()
+
+	for i, row := range entropyBoard {
+		for j, ids := range row {
+			if buildBoard[i][j].placed || len(ids) == 0 || !g.onScreen(i, j) {
+				continue
+			}
+
+			weights := make([]float64, len(ids))
+			total := 0.0
+			for k, id := range ids {
+				weights[k] = 1
+				if g.Rules.Randomiser == SimpleWeighted {
+					weights[k] = float64(g.Cards[id].chance)
+				}
+				total += weights[k]
+			}
+			if total == 0 {
+				continue
+			}
+
+			// adding the scaled images together gives their weighted average
+			for k, id := range ids {
+				w := float32(superpositionOpacity * weights[k] / total)
+				op := &ebiten.DrawImageOptions{Blend: ebiten.BlendLighter}
+				op.ColorScale.Scale(w, w, w, w)
+				x, y := g.cellPosition(i, j)
+				drawCard(screen, g.Cards[id], x, y, cam, op)
+			}
+		}
+	}
+}
+
+// currentBuildBoard returns the build state of the board, creating it if the board hasn't been stepped through
+func (g *Game) currentBuildBoard() [][]buildCell {
+	if g.buildBoard == nil {
+		g.buildBoard = getBuildBoard(g)
+		g.pending = nil
+	}
+	return g.buildBoard
+}
+
+func (g *Game) overlayValue(ids []int) float64 {
+	if g.overlay == overlayEntropy {
+		return weightedEntropy(g, ids)
+	}
+	return float64(len(ids))
+}
+
+// heatColour goes from red at 0 to blue at 1
+func heatColour(v float64) color.RGBA {
+	v = min(max(v, 0), 1)
+	// premultiplied alpha, so the colour channels can't be more than the alpha
+	const alpha = 150
+	return color.RGBA{uint8(alpha * (1 - v)), 0, uint8(alpha * v), alpha}
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// the palette of cards is drawn down the right hand side of the screen
+const (
+	paletteColumns = 4
+	paletteSpacing = tileSize + 4
+)
+
+var (
+	lockedColour   = color.RGBA{0, 255, 255, 255}
+	selectedColour = color.RGBA{255, 255, 255, 255}
+	paletteColour  = color.RGBA{32, 32, 32, 230}
+)
+
+type cellPos struct {
+	x int
+	y int
+}
+
+// updatePalette handles the mouse for choosing and locking cards
+func (g *Game) updatePalette() {
+	if inpututil.IsKeyJustPressed(keyClose) {
+		g.paletteCell = nil
+	}
+
+	mx, my := ebiten.CursorPosition()
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if id, ok := g.paletteCardAt(mx, my); ok {
+			g.LockTile(g.paletteCell.x, g.paletteCell.y, id)
+			g.status = fmt.Sprintf("locked card %d at (%d, %d)", id, g.paletteCell.x, g.paletteCell.y)
+			g.paletteCell = nil
+			g.restart(g.Seed)
+			return
+		}
+		if i, j, ok := g.cellAt(mx, my); ok && !g.Masked(i, j) {
+			g.paletteCell = &cellPos{i, j}
+			g.palette = g.CompatibleCards(i, j)
+			if len(g.palette) == 0 {
+				g.status = fmt.Sprintf("no cards fit at (%d, %d)", i, j)
+			}
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		if i, j, ok := g.cellAt(mx, my); ok && g.Locked(i, j) {
+			g.UnlockTile(i, j)
+			g.status = fmt.Sprintf("unlocked (%d, %d)", i, j)
+			g.restart(g.Seed)
+		}
+	}
+}
+
+func (g *Game) paletteOrigin() (x, y int) {
+	return g.screenWidth - paletteColumns*paletteSpacing, boardOffset
+}
+
+// paletteCardAt returns the card in the open palette under the screen position
+func (g *Game) paletteCardAt(x, y int) (int, bool) {
+	if g.paletteCell == nil {
+		return 0, false
+	}
+	px, py := g.paletteOrigin()
+	if x < px || y < py {
+		return 0, false
+	}
+	column := (x - px) / paletteSpacing
+	row := (y - py) / paletteSpacing
+	n := row*paletteColumns + column
+	if column >= paletteColumns || n >= len(g.palette) {
+		return 0, false
+	}
+	return g.palette[n], true
+}
+
+func (g *Game) drawPalette(screen *ebiten.Image) {
+	if g.paletteCell == nil {
+		return
+	}
+
+	x, y, size := g.screenRect(g.paletteCell.x, g.paletteCell.y)
+	vector.StrokeRect(screen, x, y, size, size, 2, selectedColour, false)
+
+	px, py := g.paletteOrigin()
+	rows := (len(g.palette) + paletteColumns - 1) / paletteColumns
+	vector.DrawFilledRect(screen, float32(px-4), float32(py-4), paletteColumns*paletteSpacing+4, float32(rows*paletteSpacing+4), paletteColour, false)
+
+	for n, id := range g.palette {
+		cx := px + (n%paletteColumns)*paletteSpacing
+		cy := py + (n/paletteColumns)*paletteSpacing
+		drawCard(screen, g.Cards[id], float64(cx), float64(cy), ebiten.GeoM{}, &ebiten.DrawImageOptions{})
+	}
+}
+
+// drawLocked outlines the cells that are locked by a seed tile
+func (g *Game) drawLocked(screen *ebiten.Image) {
+	for _, seedTile := range g.Rules.SeedTiles {
+		x, y, size := g.screenRect(seedTile.X, seedTile.Y)
+		vector.StrokeRect(screen, x+1, y+1, size-2, size-2, 1, lockedColour, false)
+	}
+}
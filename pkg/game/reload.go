@@ -0,0 +1,121 @@
+package game
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"wfc2/pkg/boiler"
+)
+
+// watchInterval is how many updates there are between checking the files for changes
+const watchInterval = 30
+
+// watcher polls the modification times of the rules file and the images it uses
+type watcher struct {
+	modTimes map[string]time.Time
+	updates  int
+}
+
+// WatchFiles reloads the rules and images whenever they change, checking every few updates
+// it is only useful when the game's filesystem is on disk rather than embedded
+func (g *Game) WatchFiles() {
+	g.watch = &watcher{modTimes: watchedModTimes(g.Fs, g.Rules)}
+}
+
+// updateWatch checks the files for changes every watchInterval updates
+func (g *Game) updateWatch() {
+	if g.watch == nil {
+		return
+	}
+	g.watch.updates++
+	if g.watch.updates%watchInterval == 0 {
+		g.checkFiles()
+	}
+}
+
+// checkFiles reloads the rules and cards if any of the files have changed since they were last loaded
+// and regenerates the board with the same seed, if the new rules don't load the current ones are kept
+// tiles locked since the rules were loaded are kept if they still fit the new rules
+// it returns true if the rules were reloaded
+func (g *Game) checkFiles() bool {
+	modTimes := watchedModTimes(g.Fs, g.Rules)
+	if sameModTimes(g.watch.modTimes, modTimes) {
+		return false
+	}
+	g.watch.modTimes = modTimes
+
+	rules, cards, err := reloadRules(g.Fs)
+	if err != nil {
+		g.status = fmt.Sprintf("reload failed, keeping the previous tileset: %v", err)
+		return false
+	}
+
+	// the images might have changed as well as the rules, so they are watched again
+	g.watch.modTimes = watchedModTimes(g.Fs, rules)
+	dropped := g.replaceRules(rules, cards, nil)
+
+	if g.editor.active {
+		g.editor = editor{active: true, rules: g.editorRules()}
+	}
+	g.status = fmt.Sprintf("reloaded %s", DefaultRulesFile)
+	if dropped > 0 {
+		g.status += fmt.Sprintf(", unlocked %d tiles that don't fit the new rules", dropped)
+	}
+	g.restart(g.Seed)
+	return true
+}
+
+// reloadRules is LoadRules and BuildCards, returning an error rather than panicking
+func reloadRules(fsys fs.FS) (BasicRules, map[int]*Card, error) {
+	data, err := boiler.ReadJsonFromDisk(fsys, DefaultRulesFile)
+	if err != nil {
+		return BasicRules{}, nil, err
+	}
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		return BasicRules{}, nil, err
+	}
+	err = rules.Validate(fsys)
+	if err != nil {
+		return BasicRules{}, nil, err
+	}
+	if rules.Topology == Voxel {
+		return BasicRules{}, nil, ErrVoxelBoard
+	}
+	cards, err := LoadCards(rules, fsys)
+	if err != nil {
+		return BasicRules{}, nil, err
+	}
+	return rules, cards, nil
+}
+
+// watchedModTimes returns the modification times of the rules file and its images
+// files that can't be found have the zero time, so they are seen to change when they come back
+func watchedModTimes(fsys fs.FS, rules BasicRules) map[string]time.Time {
+	modTimes := map[string]time.Time{DefaultRulesFile: {}}
+	for _, baseCard := range rules.BaseCards {
+		if baseCard.Filename != "" {
+			modTimes[baseCard.Filename] = time.Time{}
+		}
+	}
+	for name := range modTimes {
+		info, err := fs.Stat(fsys, name)
+		if err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, t := range a {
+		if u, ok := b[name]; !ok || !t.Equal(u) {
+			return false
+		}
+	}
+	return true
+}
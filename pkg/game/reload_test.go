@@ -0,0 +1,80 @@
+package game
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func Test_checkFiles(t *testing.T) {
+	fsys := getFS().(fstest.MapFS)
+	rules := fsys[DefaultRulesFile]
+	rules.ModTime = time.Unix(1000, 0)
+
+	g := NewGame(fsys, 42)
+	g.WatchFiles()
+
+	if g.checkFiles() {
+		t.Errorf("reloaded when nothing changed")
+	}
+
+	// a valid change is loaded and the board is regenerated with the same seed
+	data := strings.Replace(string(rules.Data), `"boardWidth": 20`, `"boardWidth": 12`, 1)
+	rules.Data = []byte(strings.Replace(data, `"id":0`, `"id":1`, 1))
+	rules.ModTime = time.Unix(2000, 0)
+	if !g.checkFiles() {
+		t.Fatalf("didn't reload the changed rules: %s", g.status)
+	}
+	if g.Rules.BoardWidth != 12 || len(g.Board) != 12 {
+		t.Errorf("board width got %d with %d rows, want 12", g.Rules.BoardWidth, len(g.Board))
+	}
+	if g.Seed != 42 {
+		t.Errorf("seed got %d, want 42", g.Seed)
+	}
+
+	// an invalid change keeps the previous tileset
+	cards := g.Cards
+	rules.Data = []byte(strings.Replace(string(rules.Data), `"connectors":"GGGG"`, `"connectors":"GGXG"`, 1))
+	rules.ModTime = time.Unix(3000, 0)
+	if g.checkFiles() {
+		t.Errorf("reloaded invalid rules")
+	}
+	if g.Rules.BoardWidth != 12 || len(g.Cards) != len(cards) || g.Cards[1] != cards[1] {
+		t.Errorf("previous tileset not kept")
+	}
+	if !strings.Contains(g.status, "reload failed") {
+		t.Errorf("status got %q, want the reload failure", g.status)
+	}
+}
+
+func Test_checkFilesKeepsLocked(t *testing.T) {
+	fsys := getFS().(fstest.MapFS)
+	rules := fsys[DefaultRulesFile]
+	rules.Data = []byte(strings.Replace(string(rules.Data), `"id":0`, `"id":1`, 1))
+	rules.ModTime = time.Unix(1000, 0)
+
+	g := NewGame(fsys, 42)
+	g.WatchFiles()
+	g.LockTile(5, 5, 2)
+	g.LockTile(15, 2, 3)
+
+	// shrinking the board leaves the second locked tile off it
+	rules.Data = []byte(strings.Replace(string(rules.Data), `"boardWidth": 20`, `"boardWidth": 12`, 1))
+	rules.ModTime = time.Unix(2000, 0)
+	if !g.checkFiles() {
+		t.Fatalf("didn't reload the changed rules: %s", g.status)
+	}
+
+	want := []SeedTiles{{0, 0, 1}, {5, 5, 2}}
+	if !reflect.DeepEqual(g.Rules.SeedTiles, want) {
+		t.Errorf("seed tiles got %v, want %v", g.Rules.SeedTiles, want)
+	}
+	if g.Board[5][5].Card == nil || g.Board[5][5].Card.Id != 2 {
+		t.Errorf("locked tile not placed after the reload, got %v", g.Board[5][5].Card)
+	}
+	if !strings.Contains(g.status, "unlocked 1 tiles") {
+		t.Errorf("status got %q, want it to mention the unlocked tile", g.status)
+	}
+}
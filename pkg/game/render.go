@@ -0,0 +1,163 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// colours used when a card has no image and is drawn from its connectors
+var (
+	grassColour = color.RGBA{0x5a, 0x9e, 0x3a, 0xff}
+	roadColour  = color.RGBA{0xc2, 0xa3, 0x6b, 0xff}
+)
+
+// RenderImage draws the board into an image without needing ebiten to be running
+// cards without an image are drawn as grass with roads to each road connector
+func (g *Game) RenderImage() *image.RGBA {
+	size := g.Rules.ImageSize
+	rows := len(g.Board)
+	columns := 0
+	if rows > 0 {
+		columns = len(g.Board[0])
+	}
+	if g.Rules.Topology == Hex {
+		return g.renderHexImage(rows, columns)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, columns*size, rows*size))
+
+	// every rotation of a card is only rotated once
+	type rotated struct{ base, rotation int }
+	cache := make(map[rotated]image.Image)
+
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if tile.Card == nil {
+				continue
+			}
+			r := image.Rect(j*size, i*size, (j+1)*size, (i+1)*size)
+			if tile.Card.Image == nil || tile.Card.Image.src == nil {
+				drawConnectors(dst, r, tile.Card.Connectors)
+				continue
+			}
+			key := rotated{tile.Card.Base, tile.Card.Rotation}
+			img, ok := cache[key]
+			if !ok {
+				img = rotateImage(tile.Card.Image.src, tile.Card.Rotation)
+				cache[key] = img
+			}
+			draw.Draw(dst, r, img, img.Bounds().Min, draw.Over)
+		}
+	}
+
+	return dst
+}
+
+// renderHexImage draws a hex board, each hex is ImageSize wide
+func (g *Game) renderHexImage(rows, columns int) *image.RGBA {
+	size := float64(g.Rules.ImageSize)
+	width, height := hexBoardSize(rows, columns, size)
+	dst := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(width)), int(math.Ceil(height))))
+
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if tile.Card == nil {
+				continue
+			}
+			cx, cy := hexCentre(i, j, size)
+			renderHex(dst, tile.Card, cx, cy, size)
+		}
+	}
+
+	return dst
+}
+
+// drawConnectors draws a grass square into r with a road from the centre to the middle of
+// each edge that has a road connector, matching the svg drawn by writeSVGConnectors
+func drawConnectors(dst draw.Image, r image.Rectangle, connectors []Connector) {
+	s := r.Dx()
+	road := s / 4
+	half := s / 2
+	grass := image.NewUniform(grassColour)
+	roadFill := image.NewUniform(roadColour)
+
+	draw.Draw(dst, r, grass, image.Point{}, draw.Src)
+
+	hasRoad := false
+	for k, c := range connectors {
+		if c != Road {
+			continue
+		}
+		hasRoad = true
+		var part image.Rectangle
+		// connectors are always north, east, south, west
+		switch k {
+		case 0:
+			part = image.Rect(half-road/2, 0, half+road/2, half)
+		case 1:
+			part = image.Rect(half, half-road/2, s, half+road/2)
+		case 2:
+			part = image.Rect(half-road/2, half, half+road/2, s)
+		case 3:
+			part = image.Rect(0, half-road/2, half, half+road/2)
+		}
+		draw.Draw(dst, part.Add(r.Min), roadFill, image.Point{}, draw.Src)
+	}
+	if hasRoad {
+		centre := image.Rect(half-road/2, half-road/2, half+road/2, half+road/2)
+		draw.Draw(dst, centre.Add(r.Min), roadFill, image.Point{}, draw.Src)
+	}
+}
+
+// rotateImage returns a copy of src rotated clockwise by rotation degrees
+// only multiples of 90 are supported, anything else is copied unrotated
+func rotateImage(src image.Image, rotation int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	rotation = ((rotation % 360) + 360) % 360
+	var dst *image.RGBA
+	if rotation == 90 || rotation == 270 {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := src.At(b.Min.X+x, b.Min.Y+y)
+			switch rotation {
+			case 90:
+				dst.Set(h-1-y, x, c)
+			case 180:
+				dst.Set(w-1-x, h-1-y, c)
+			case 270:
+				dst.Set(y, w-1-x, c)
+			default:
+				dst.Set(x, y, c)
+			}
+		}
+	}
+
+	return dst
+}
+
+// baseImage returns the decoded image of the unrotated card built from the base card
+func (g *Game) baseImage(base int) image.Image {
+	card := g.baseCard(base)
+	if card == nil || card.Image == nil {
+		return nil
+	}
+	return card.Image.src
+}
+
+// baseCard returns the unrotated card built from the base card
+func (g *Game) baseCard(base int) *Card {
+	for _, card := range g.Cards {
+		if card.Base == base && card.Rotation == 0 {
+			return card
+		}
+	}
+	return nil
+}
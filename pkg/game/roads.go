@@ -0,0 +1,94 @@
+package game
+
+import "slices"
+
+// connectedCard returns a card from the ids for (x, y) that keeps the roads able to join up into a single network
+// cards are picked the same way as without the constraint, dropping the ones that would cut off part of the roads
+// if every card would, the first one picked is returned with connected false, as there is nothing better without going back
+func (g *Game) connectedCard(board [][]buildCell, x, y int, ids []int) (id int, connected bool) {
+	first := g.pickCard(ids)
+	remaining := ids
+	id = first
+	for !g.roadsConnectedWith(board, x, y, id) {
+		remaining = slices.DeleteFunc(slices.Clone(remaining), func(r int) bool { return r == id })
+		if len(remaining) == 0 {
+			return first, false
+		}
+		id = g.pickCard(remaining)
+	}
+	return id, true
+}
+
+// RoadsSplit reports whether a card that cut off part of the roads had to be placed, because no card
+// that fitted kept them connected, it is only set with ConnectedRoads and is cleared by Reset
+func (g *Game) RoadsSplit() bool {
+	return g.roadsSplit
+}
+
+// roadsConnectedWith is roadsConnected with the card placed at (x, y)
+func (g *Game) roadsConnectedWith(board [][]buildCell, x, y, id int) bool {
+	previous := board[x][y]
+	board[x][y] = buildCell{placed: true, connectors: g.Cards[id].Connectors, id: id}
+	defer func() { board[x][y] = previous }()
+	return g.roadsConnected(board)
+}
+
+// roadsConnected reports whether every card with a road could still be joined into one road network
+// it is optimistic, empty cells let a road through between any of their sides, and the roads on a card are
+// taken to all join up, cells that are only connectors, like masked cells, don't let roads through
+func (g *Game) roadsConnected(board [][]buildCell) bool {
+	rows, columns := len(board), len(board[0])
+	offsets := g.Rules.Topology.offsets()
+
+	// open reports whether a road can leave the cell on the side
+	open := func(cell buildCell, side int) bool {
+		if !cell.placed {
+			return true
+		}
+		return cell.id != 0 && cell.connectors[side] == Road
+	}
+	hasRoad := func(cell buildCell) bool {
+		return cell.placed && cell.id != 0 && slices.Contains(cell.connectors, Road)
+	}
+
+	roads := 0
+	var start [2]int
+	for i, row := range board {
+		for j, cell := range row {
+			if hasRoad(cell) {
+				roads++
+				start = [2]int{i, j}
+			}
+		}
+	}
+	if roads == 0 {
+		return true
+	}
+
+	visited := make([][]bool, rows)
+	for i := range visited {
+		visited[i] = make([]bool, columns)
+	}
+	visited[start[0]][start[1]] = true
+	queue := [][2]int{start}
+	reached := 0
+	for len(queue) > 0 {
+		i, j := queue[0][0], queue[0][1]
+		queue = queue[1:]
+		if hasRoad(board[i][j]) {
+			reached++
+		}
+		for side, offset := range offsets {
+			if !open(board[i][j], side) {
+				continue
+			}
+			ni, nj, ok := neighbour(rows, columns, i, j, offset, g.Rules.Wrap)
+			if !ok || visited[ni][nj] || !open(board[ni][nj], g.Rules.Topology.opposite(side)) {
+				continue
+			}
+			visited[ni][nj] = true
+			queue = append(queue, [2]int{ni, nj})
+		}
+	}
+	return reached == roads
+}
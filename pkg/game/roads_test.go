@@ -0,0 +1,135 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func getRoadsGame(seed uint64, connected bool) *Game {
+	fs := getFS()
+	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules.BoardWidth, rules.BoardHeight = 6, 8
+	rules.SeedTiles = nil
+	rules.ConnectedRoads = connected
+	return NewGameWithRules(fs, rules, BuildCards(rules, fs), seed)
+}
+
+// roadNetworks counts the separate road networks on the board, following the roads between the cards
+func roadNetworks(board [][]Tile) int {
+	visited := map[[2]int]bool{}
+	networks := 0
+	for i, row := range board {
+		for j, tile := range row {
+			if tile.Card == nil || visited[[2]int{i, j}] || !slices.Contains(tile.Card.Connectors, Road) {
+				continue
+			}
+			networks++
+			stack := [][2]int{{i, j}}
+			visited[[2]int{i, j}] = true
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				card := board[p[0]][p[1]].Card
+				for side, offset := range sideOffsets {
+					ni, nj, ok := neighbour(len(board), len(row), p[0], p[1], offset, WrapNone)
+					if !ok || card.Connectors[side] != Road || board[ni][nj].Card == nil || visited[[2]int{ni, nj}] {
+						continue
+					}
+					visited[[2]int{ni, nj}] = true
+					stack = append(stack, [2]int{ni, nj})
+				}
+			}
+		}
+	}
+	return networks
+}
+
+func Test_ConnectedRoads(t *testing.T) {
+	disconnected := 0
+	for seed := uint64(1); seed <= 10; seed++ {
+		if g := getRoadsGame(seed, false); g.Generate() && roadNetworks(g.Board) > 1 {
+			disconnected++
+		}
+
+		g := getRoadsGame(seed, true)
+		if !g.Generate() {
+			t.Errorf("seed %d: board didn't complete", seed)
+			continue
+		}
+		if n := roadNetworks(g.Board); n != 1 {
+			t.Errorf("seed %d: got %d road networks, want 1", seed, n)
+		}
+	}
+	if disconnected == 0 {
+		t.Errorf("expected some boards without the constraint to have several road networks")
+	}
+}
+
+func Test_ConnectedRoadsSplit(t *testing.T) {
+	// with only dead ends, the two ends facing each other across the gap can't be joined
+	// whichever end goes next to one of them cuts the other off
+	rules := BasicRules{
+		ImageSize:      32,
+		BoardWidth:     1,
+		BoardHeight:    4,
+		BaseCards:      []BaseCards{{Connectors: "GGGR", Rotations: []int{90, 180, 270}, Chance: 1}},
+		ConnectedRoads: true,
+	}
+	cards := BuildCards(rules, getFS())
+	facing := func(side int) int {
+		for id, card := range cards {
+			if card.Connectors[side] == Road {
+				return id
+			}
+		}
+		return 0
+	}
+	rules.SeedTiles = []SeedTiles{{0, 0, facing(1)}, {0, 3, facing(3)}}
+
+	g := NewGameWithRules(getFS(), rules, cards, 1)
+	if g.Generate() {
+		t.Errorf("generate should fail when the roads had to be split")
+	}
+	if !g.RoadsSplit() || !g.Complete() {
+		t.Errorf("got split %v and complete %v, want a complete board with the roads split", g.RoadsSplit(), g.Complete())
+	}
+
+	g.Reset(2)
+	if g.RoadsSplit() {
+		t.Errorf("reset should clear the split")
+	}
+}
+
+func Test_roadsConnected(t *testing.T) {
+	g := getRoadsGame(1, true)
+	board := getBuildBoard(g)
+	place := func(i, j, id int) {
+		board[i][j] = buildCell{placed: true, connectors: g.Cards[id].Connectors, id: id}
+	}
+
+	// two horizontal straights, with empty cells between them the roads could still join
+	place(0, 0, 3)
+	place(0, 4, 3)
+	if !g.roadsConnected(board) {
+		t.Errorf("roads with empty cells between them should be able to join")
+	}
+
+	// walling off the second straight with grass cuts it off
+	for _, p := range [][2]int{{0, 3}, {1, 4}, {0, 5}} {
+		place(p[0], p[1], 1)
+	}
+	if g.roadsConnected(board) {
+		t.Errorf("a road surrounded by grass should be cut off")
+	}
+
+	// masked cells don't let roads through either
+	board = getBuildBoard(g)
+	place(0, 0, 3)
+	place(0, 4, 3)
+	for i := range board {
+		board[i][2] = g.maskedCell()
+	}
+	if g.roadsConnected(board) {
+		t.Errorf("a road across the mask should be cut off")
+	}
+}
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// seed keys
+//
+//	t      type a seed, enter to use it and escape to cancel
+//	,      back to the previously viewed seed
+//	.      forward to the next viewed seed
+//	c      copy the seed to the clipboard
+//	f12    save the board as a png
+const (
+	keyTypeSeed    = ebiten.KeyT
+	keyBack        = ebiten.KeyComma
+	keyForward     = ebiten.KeyPeriod
+	keyCopySeed    = ebiten.KeyC
+	keyScreenshot  = ebiten.KeyF12
+	keyDeleteDigit = ebiten.KeyBackspace
+)
+
+// seedHistory is the seeds that have been viewed, like the history of a browser
+type seedHistory struct {
+	seeds []uint64
+	index int
+}
+
+// push adds the seed after the current one, dropping anything that had been gone back past
+func (h *seedHistory) push(seed uint64) {
+	if len(h.seeds) > 0 {
+		h.seeds = h.seeds[:h.index+1]
+	}
+	h.seeds = append(h.seeds, seed)
+	h.index = len(h.seeds) - 1
+}
+
+func (h *seedHistory) back() (uint64, bool) {
+	if h.index == 0 || len(h.seeds) == 0 {
+		return 0, false
+	}
+	h.index--
+	return h.seeds[h.index], true
+}
+
+func (h *seedHistory) forward() (uint64, bool) {
+	if h.index >= len(h.seeds)-1 {
+		return 0, false
+	}
+	h.index++
+	return h.seeds[h.index], true
+}
+
+// viewSeed regenerates the board with a new seed and remembers it in the history
+func (g *Game) viewSeed(seed uint64) {
+	if len(g.history.seeds) == 0 {
+		g.history.push(g.Seed)
+	}
+	g.history.push(seed)
+	g.restart(seed)
+}
+
+// updateSeeds handles the seed keys, it returns true while a seed is being typed
+// so that the digits aren't used by anything else
+func (g *Game) updateSeeds() bool {
+	if g.typedSeed != nil {
+		g.updateTypedSeed()
+		return true
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(keyTypeSeed):
+		typed := ""
+		g.typedSeed = &typed
+		return true
+	case inpututil.IsKeyJustPressed(keyBack):
+		if seed, ok := g.history.back(); ok {
+			g.restart(seed)
+		}
+	case inpututil.IsKeyJustPressed(keyForward):
+		if seed, ok := g.history.forward(); ok {
+			g.restart(seed)
+		}
+	case inpututil.IsKeyJustPressed(keyCopySeed):
+		g.copySeed()
+	case inpututil.IsKeyJustPressed(keyScreenshot):
+		g.screenshot()
+	}
+	return false
+}
+
+func (g *Game) updateTypedSeed() {
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if r >= '0' && r <= '9' {
+			*g.typedSeed += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(keyDeleteDigit) && len(*g.typedSeed) > 0 {
+		*g.typedSeed = (*g.typedSeed)[:len(*g.typedSeed)-1]
+	}
+
+	if inpututil.IsKeyJustPressed(keyClose) {
+		g.typedSeed = nil
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
+		seed, err := strconv.ParseUint(*g.typedSeed, 10, 64)
+		g.typedSeed = nil
+		if err != nil {
+			g.status = fmt.Sprintf("not a seed: %v", err)
+			return
+		}
+		g.viewSeed(seed)
+	}
+}
+
+// copySeed puts the seed on the clipboard using the osc 52 terminal escape sequence, which most terminals
+// support, even over ssh, when standard output isn't a terminal the seed is only printed
+func (g *Game) copySeed() {
+	info, err := os.Stdout.Stat()
+	terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+	g.status = writeSeed(os.Stdout, g.Seed, terminal)
+}
+
+// writeSeed writes the seed to w, with the escape sequence that copies it if w is a terminal,
+// and returns the status to show
+func writeSeed(w io.Writer, seed uint64, terminal bool) string {
+	s := strconv.FormatUint(seed, 10)
+	if !terminal {
+		fmt.Fprintln(w, "seed:", s)
+		return fmt.Sprintf("no terminal to copy with, printed seed %s", s)
+	}
+	fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(s)))
+	fmt.Fprintln(w, "seed:", s)
+	return fmt.Sprintf("copied seed %s", s)
+}
+
+// screenshot saves the whole board, not just what is on the screen, as a png in the working directory
+func (g *Game) screenshot() {
+	filename := fmt.Sprintf("wfc-%d-%s.png", g.Seed, time.Now().Format("20060102-150405"))
+	f, err := os.Create(filename)
+	if err != nil {
+		g.status = fmt.Sprintf("screenshot failed: %v", err)
+		return
+	}
+	defer f.Close()
+
+	err = png.Encode(f, g.RenderImage())
+	if err != nil {
+		g.status = fmt.Sprintf("screenshot failed: %v", err)
+		return
+	}
+	g.status = fmt.Sprintf("saved %s", filename)
+}
+
+func (g *Game) seedText() string {
+	if g.typedSeed != nil {
+		return fmt.Sprintf("Seed: %s_", *g.typedSeed)
+	}
+	return fmt.Sprintf("Seed: %d", g.Seed)
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_seedHistory(t *testing.T) {
+	var h seedHistory
+
+	if _, ok := h.back(); ok {
+		t.Errorf("empty history should not go back")
+	}
+	if _, ok := h.forward(); ok {
+		t.Errorf("empty history should not go forward")
+	}
+
+	h.push(1)
+	h.push(2)
+	h.push(3)
+
+	if seed, ok := h.back(); !ok || seed != 2 {
+		t.Errorf("back got (%d, %v), want 2", seed, ok)
+	}
+	if seed, ok := h.back(); !ok || seed != 1 {
+		t.Errorf("back got (%d, %v), want 1", seed, ok)
+	}
+	if _, ok := h.back(); ok {
+		t.Errorf("should not go back past the first seed")
+	}
+	if seed, ok := h.forward(); !ok || seed != 2 {
+		t.Errorf("forward got (%d, %v), want 2", seed, ok)
+	}
+
+	// viewing a new seed drops the ones that had been gone back past
+	h.push(4)
+	if _, ok := h.forward(); ok {
+		t.Errorf("should not go forward after a new seed")
+	}
+	if seed, ok := h.back(); !ok || seed != 2 {
+		t.Errorf("back got (%d, %v), want 2", seed, ok)
+	}
+	if len(h.seeds) != 3 {
+		t.Errorf("got history %v, want [1 2 4]", h.seeds)
+	}
+}
+
+func Test_writeSeed(t *testing.T) {
+	var buf bytes.Buffer
+	status := writeSeed(&buf, 42, true)
+	// 42 in base64 is NDI=
+	if want := "\x1b]52;c;NDI=\aseed: 42\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	if status != "copied seed 42" {
+		t.Errorf("got status %q", status)
+	}
+
+	buf.Reset()
+	status = writeSeed(&buf, 42, false)
+	if want := "seed: 42\n"; buf.String() != want {
+		t.Errorf("without a terminal got %q, want %q", buf.String(), want)
+	}
+	if status != "no terminal to copy with, printed seed 42" {
+		t.Errorf("without a terminal got status %q", status)
+	}
+}
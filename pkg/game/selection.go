@@ -0,0 +1,66 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+var selectionColour = color.RGBA{255, 128, 0, 255}
+
+// updateSelection handles shift dragging a rectangle of the board to reroll it
+// it returns true while a selection is being made, so the click isn't used for anything else
+func (g *Game) updateSelection() bool {
+	mx, my := ebiten.CursorPosition()
+
+	if g.selectFrom == nil {
+		if !ebiten.IsKeyPressed(ebiten.KeyShift) || !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			return false
+		}
+		i, j, ok := g.cellAt(mx, my)
+		if !ok {
+			return false
+		}
+		g.selectFrom = &cellPos{i, j}
+		g.selectTo = cellPos{i, j}
+		return true
+	}
+
+	if i, j, ok := g.cellAt(mx, my); ok {
+		g.selectTo = cellPos{i, j}
+	}
+
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		from, to := *g.selectFrom, g.selectTo
+		g.selectFrom = nil
+		g.playing = false
+
+		seed := rand.Uint64()
+		err := g.RerollRegion(from.x, from.y, to.x, to.y, seed)
+		switch {
+		case errors.Is(err, ErrUnsolvable):
+			g.status = fmt.Sprintf("reroll failed, %v", err)
+		case err != nil:
+			g.status = err.Error()
+		default:
+			g.status = fmt.Sprintf("rerolled (%d, %d) to (%d, %d) with seed %d", from.x, from.y, to.x, to.y, seed)
+		}
+	}
+
+	return true
+}
+
+func (g *Game) drawSelection(screen *ebiten.Image) {
+	if g.selectFrom == nil {
+		return
+	}
+
+	x0, y0, _ := g.screenRect(min(g.selectFrom.x, g.selectTo.x), min(g.selectFrom.y, g.selectTo.y))
+	x1, y1, _ := g.screenRect(max(g.selectFrom.x, g.selectTo.x)+1, max(g.selectFrom.y, g.selectTo.y)+1)
+	vector.StrokeRect(screen, x0, y0, x1-x0, y1-y0, 2, selectionColour, false)
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+var (
+	svgGrassColour = svgColour(grassColour)
+	svgRoadColour  = svgColour(roadColour)
+)
+
+// WriteSVG writes the board as an svg document
+// cards with an image are embedded as a png and rotated into place, cards without one
+// are drawn as grass with a road running from the centre to each road connector
+func (g *Game) WriteSVG(w io.Writer) error {
+	size := g.Rules.ImageSize
+	rows := len(g.Board)
+	columns := 0
+	if rows > 0 {
+		columns = len(g.Board[0])
+	}
+
+	width, height := float64(columns*size), float64(rows*size)
+	if g.Rules.Topology == Hex {
+		width, height = hexBoardSize(rows, columns, float64(size))
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" shape-rendering="crispEdges">`+"\n",
+		width, height, width, height)
+
+	// each image is only embedded once, and referenced by every tile that uses it
+	fmt.Fprintln(bw, "<defs>")
+	for base := range g.Rules.BaseCards {
+		src := g.baseImage(base)
+		if src == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		err := png.Encode(&buf, src)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, `<image id="card-%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+			base, size, size, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	fmt.Fprintln(bw, "</defs>")
+
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if tile.Card == nil {
+				continue
+			}
+			if g.Rules.Topology == Hex {
+				writeSVGHex(bw, tile.Card, i, j, size)
+				continue
+			}
+			x, y := j*size, i*size
+			if tile.Card.Image != nil && tile.Card.Image.src != nil {
+				fmt.Fprintf(bw, `<use href="#card-%d" transform="translate(%d %d) rotate(%d %g %g)"/>`+"\n",
+					tile.Card.Base, x, y, tile.Card.Rotation, float64(size)/2, float64(size)/2)
+			} else {
+				writeSVGConnectors(bw, tile.Card.Connectors, x, y, size)
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// writeSVGConnectors draws a card from its connectors, a grass square with a road
+// from the centre out to the middle of each edge that has a road connector
+func writeSVGConnectors(w io.Writer, connectors []Connector, x, y, size int) {
+	s := float64(size)
+	road := s / 4
+	half := s / 2
+
+	fmt.Fprintf(w, `<g transform="translate(%d %d)">`, x, y)
+	fmt.Fprintf(w, `<rect width="%g" height="%g" fill="%s"/>`, s, s, svgGrassColour)
+
+	hasRoad := false
+	for k, c := range connectors {
+		if c != Road {
+			continue
+		}
+		hasRoad = true
+		// connectors are always north, east, south, west
+		switch k {
+		case 0:
+			fmt.Fprintf(w, `<rect x="%g" y="0" width="%g" height="%g" fill="%s"/>`, half-road/2, road, half, svgRoadColour)
+		case 1:
+			fmt.Fprintf(w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`, half, half-road/2, half, road, svgRoadColour)
+		case 2:
+			fmt.Fprintf(w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`, half-road/2, half, road, half, svgRoadColour)
+		case 3:
+			fmt.Fprintf(w, `<rect x="0" y="%g" width="%g" height="%g" fill="%s"/>`, half-road/2, half, road, svgRoadColour)
+		}
+	}
+	if hasRoad {
+		fmt.Fprintf(w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`, half-road/2, half-road/2, road, road, svgRoadColour)
+	}
+
+	fmt.Fprintln(w, "</g>")
+}
+
+// writeSVGHex draws the card in its hex, the image is clipped to the hex and rotated into place,
+// cards without one are drawn as grass with a road from the centre to each side with a road connector
+func writeSVGHex(w io.Writer, card *Card, i, j, size int) {
+	s := float64(size)
+	radius := s / 2
+	cx, cy := hexCentre(i, j, s)
+
+	var points []string
+	for _, corner := range hexCorners(0, 0, radius) {
+		points = append(points, fmt.Sprintf("%g,%g", corner[0], corner[1]))
+	}
+	hex := strings.Join(points, " ")
+
+	fmt.Fprintf(w, `<g transform="translate(%g %g)">`, cx, cy)
+	if card.Image != nil && card.Image.src != nil {
+		fmt.Fprintf(w, `<clipPath id="hex-%d-%d"><polygon points="%s"/></clipPath>`, i, j, hex)
+		fmt.Fprintf(w, `<g clip-path="url(#hex-%d-%d)"><use href="#card-%d" transform="rotate(%d) translate(%g %g)"/></g>`,
+			i, j, card.Base, card.Rotation, -radius, -radius)
+		fmt.Fprintln(w, "</g>")
+		return
+	}
+
+	fmt.Fprintf(w, `<polygon points="%s" fill="%s"/>`, hex, svgGrassColour)
+	hasRoad := false
+	for side, c := range card.Connectors {
+		if c != Road {
+			continue
+		}
+		hasRoad = true
+		ex, ey := hexEdgeMiddle(0, 0, radius, side)
+		fmt.Fprintf(w, `<line x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%g"/>`, ex, ey, svgRoadColour, radius/2)
+	}
+	if hasRoad {
+		fmt.Fprintf(w, `<circle r="%g" fill="%s"/>`, radius/4, svgRoadColour)
+	}
+	fmt.Fprintln(w, "</g>")
+}
+
+func svgColour(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_WriteSVG(t *testing.T) {
+	g := getTestGame()
+	g.Rules.ImageSize = 32
+
+	var buf bytes.Buffer
+	err := g.WriteSVG(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		`width="96" height="96" viewBox="0 0 96 96"`,
+		// the seeded cross in the centre has no image, so is drawn from its connectors
+		`<g transform="translate(32 32)"><rect width="32" height="32" fill="` + svgGrassColour + `"/>`,
+		`<rect x="12" y="0" width="8" height="16" fill="` + svgRoadColour + `"/>`,
+		`<rect x="0" y="12" width="16" height="8" fill="` + svgRoadColour + `"/>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("svg does not contain %q\n%s", want, got)
+		}
+	}
+
+	if strings.Count(got, "<g ") != 1 {
+		t.Errorf("only the placed tile should be drawn\n%s", got)
+	}
+}
+
+func Test_writeSVGConnectors(t *testing.T) {
+	t.Run("grass has no road", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeSVGConnectors(&buf, []Connector{Grass, Grass, Grass, Grass}, 0, 0, 32)
+		if strings.Contains(buf.String(), svgRoadColour) {
+			t.Errorf("grass card drew a road: %s", buf.String())
+		}
+	})
+
+	t.Run("dead end has a single road and the centre", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeSVGConnectors(&buf, []Connector{Grass, Grass, Road, Grass}, 0, 0, 32)
+		if strings.Count(buf.String(), svgRoadColour) != 2 {
+			t.Errorf("dead end should draw one road and the centre: %s", buf.String())
+		}
+	})
+}
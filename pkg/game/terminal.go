@@ -0,0 +1,81 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// box drawing characters indexed by which sides of a card have a road
+// north = 1, east = 2, south = 4, west = 8
+var roadRunes = [16]rune{'·', '╵', '╶', '└', '╷', '│', '┌', '├', '╴', '┘', '─', '┴', '┐', '┤', '┬', '┼'}
+
+const (
+	uncollapsedRune = '?'
+	maskedRune      = ' '
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiRed   = "\x1b[31m"
+)
+
+// the colour a cell is drawn in, taken from the connector the character shows
+var connectorColours = map[Connector]string{
+	Grass: "\x1b[32m",
+	Road:  "\x1b[1;33m",
+}
+
+// WriteTerminal writes the board with one box drawing character per tile, so that the
+// road network can be read at a glance. colour adds ansi colours for each connector type
+func (g *Game) WriteTerminal(w io.Writer, colour bool) error {
+	if g.Rules.Topology != Square {
+		return fmt.Errorf("can't draw a %s board in the terminal", g.Rules.Topology)
+	}
+	bw := bufio.NewWriter(w)
+	for i, row := range g.Board {
+		for j, tile := range row {
+			if colour {
+				bw.WriteString(cardColour(tile.Card))
+			}
+			if g.Masked(i, j) {
+				bw.WriteRune(maskedRune)
+				continue
+			}
+			bw.WriteRune(cardRune(tile.Card))
+		}
+		if colour {
+			bw.WriteString(ansiReset)
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+func roadMask(card *Card) int {
+	mask := 0
+	for k, c := range card.Connectors {
+		if c == Road && k < 4 {
+			mask |= 1 << k
+		}
+	}
+	return mask
+}
+
+// cardRune returns the box drawing character for the roads on the card
+func cardRune(card *Card) rune {
+	if card == nil {
+		return uncollapsedRune
+	}
+	return roadRunes[roadMask(card)]
+}
+
+func cardColour(card *Card) string {
+	if card == nil {
+		return ansiRed
+	}
+	if roadMask(card) != 0 {
+		return connectorColours[Road]
+	}
+	return connectorColours[Grass]
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_cardRune(t *testing.T) {
+	tests := []struct {
+		connectors []Connector
+		want       rune
+	}{
+		{[]Connector{Grass, Grass, Grass, Grass}, '·'},
+		{[]Connector{Road, Road, Road, Road}, '┼'},
+		{[]Connector{Road, Grass, Road, Grass}, '│'},
+		{[]Connector{Grass, Road, Grass, Road}, '─'},
+		{[]Connector{Road, Road, Grass, Grass}, '└'},
+		{[]Connector{Grass, Grass, Road, Road}, '┐'},
+		{[]Connector{Road, Grass, Grass, Grass}, '╵'},
+		{[]Connector{Grass, Road, Road, Road}, '┬'},
+	}
+
+	for _, tt := range tests {
+		got := cardRune(&Card{Connectors: tt.connectors})
+		if got != tt.want {
+			t.Errorf("connectors %v got %c, want %c", tt.connectors, got, tt.want)
+		}
+	}
+
+	if got := cardRune(nil); got != uncollapsedRune {
+		t.Errorf("empty cell got %c, want %c", got, uncollapsedRune)
+	}
+}
+
+func Test_WriteTerminal(t *testing.T) {
+	g := getTestGame()
+
+	t.Run("plain", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := g.WriteTerminal(&buf, false)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		want := "???\n?┼?\n???\n"
+		if buf.String() != want {
+			t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("colour", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := g.WriteTerminal(&buf, true)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if !strings.Contains(buf.String(), connectorColours[Road]+"┼") {
+			t.Errorf("road not coloured: %q", buf.String())
+		}
+		if strings.Count(buf.String(), ansiReset) != 3 {
+			t.Errorf("each row should reset the colour: %q", buf.String())
+		}
+	})
+}
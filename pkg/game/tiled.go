@@ -0,0 +1,230 @@
+package game
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Tiled stores flips and rotations in the top bits of each gid
+// a rotation is a diagonal flip combined with a horizontal or vertical flip
+const (
+	tiledFlipHorizontal uint32 = 0x80000000
+	tiledFlipVertical   uint32 = 0x40000000
+	tiledFlipDiagonal   uint32 = 0x20000000
+)
+
+const tiledVersion = "1.10"
+
+type tmxMap struct {
+	XMLName      xml.Name   `xml:"map"`
+	Version      string     `xml:"version,attr"`
+	Orientation  string     `xml:"orientation,attr"`
+	RenderOrder  string     `xml:"renderorder,attr"`
+	Width        int        `xml:"width,attr"`
+	Height       int        `xml:"height,attr"`
+	TileWidth    int        `xml:"tilewidth,attr"`
+	TileHeight   int        `xml:"tileheight,attr"`
+	Infinite     int        `xml:"infinite,attr"`
+	NextLayerId  int        `xml:"nextlayerid,attr"`
+	NextObjectId int        `xml:"nextobjectid,attr"`
+	Tileset      tmxTileset `xml:"tileset"`
+	Layer        tmxLayer   `xml:"layer"`
+}
+
+type tmxTileset struct {
+	FirstGid int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type tmxLayer struct {
+	Id     int     `xml:"id,attr"`
+	Name   string  `xml:"name,attr"`
+	Width  int     `xml:"width,attr"`
+	Height int     `xml:"height,attr"`
+	Data   tmxData `xml:"data"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	Csv      string `xml:",innerxml"` // digits and commas only, so safe to write unescaped
+}
+
+type tsxTileset struct {
+	XMLName    xml.Name  `xml:"tileset"`
+	Version    string    `xml:"version,attr"`
+	Name       string    `xml:"name,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+	Columns    int       `xml:"columns,attr"`
+	Tiles      []tsxTile `xml:"tile"`
+}
+
+type tsxTile struct {
+	Id    int      `xml:"id,attr"`
+	Image tsxImage `xml:"image"`
+}
+
+type tsxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+// ExportTiled writes the board to dir as <name>.tmx along with a <name>.tsx tileset
+// and copies of the card images, so the map can be opened directly in Tiled
+func (g *Game) ExportTiled(dir, name string) error {
+	if g.Rules.Topology != Square {
+		return fmt.Errorf("can't export a %s board to Tiled", g.Rules.Topology)
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// the images may live in an embedded filesystem, so copy them next to the tileset
+	copied := make(map[string]bool)
+	for _, baseCard := range g.Rules.BaseCards {
+		if baseCard.Filename == "" || copied[baseCard.Filename] {
+			continue
+		}
+		data, err := fs.ReadFile(g.Fs, baseCard.Filename)
+		if err != nil {
+			return fmt.Errorf("reading image %s: %w", baseCard.Filename, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, path.Base(baseCard.Filename)), data, 0644)
+		if err != nil {
+			return err
+		}
+		copied[baseCard.Filename] = true
+	}
+
+	tsx, err := os.Create(filepath.Join(dir, name+".tsx"))
+	if err != nil {
+		return err
+	}
+	defer tsx.Close()
+	err = g.WriteTSX(tsx, name)
+	if err != nil {
+		return err
+	}
+
+	tmx, err := os.Create(filepath.Join(dir, name+".tmx"))
+	if err != nil {
+		return err
+	}
+	defer tmx.Close()
+	return g.WriteTMX(tmx, name+".tsx")
+}
+
+// WriteTSX writes a collection of images tileset with one tile per base card
+// the tile ids are the index of the base card in the rules
+func (g *Game) WriteTSX(w io.Writer, name string) error {
+	tileset := tsxTileset{
+		Version:    tiledVersion,
+		Name:       name,
+		TileWidth:  g.Rules.ImageSize,
+		TileHeight: g.Rules.ImageSize,
+	}
+
+	for i, baseCard := range g.Rules.BaseCards {
+		if baseCard.Filename == "" {
+			continue
+		}
+		tileset.Tiles = append(tileset.Tiles, tsxTile{
+			Id: i,
+			Image: tsxImage{
+				Source: path.Base(baseCard.Filename),
+				Width:  g.Rules.ImageSize,
+				Height: g.Rules.ImageSize,
+			},
+		})
+	}
+	tileset.TileCount = len(tileset.Tiles)
+
+	return writeXML(w, tileset)
+}
+
+// WriteTMX writes the board as a single csv encoded tile layer using the tileset at tilesetSource
+func (g *Game) WriteTMX(w io.Writer, tilesetSource string) error {
+	height := len(g.Board)
+	width := 0
+	if height > 0 {
+		width = len(g.Board[0])
+	}
+
+	// the board is indexed [row][column], which is the order Tiled expects the csv in
+	var csv strings.Builder
+	csv.WriteString("\n")
+	for i, row := range g.Board {
+		for j, tile := range row {
+			csv.WriteString(fmt.Sprintf("%d", g.tiledGid(tile.Card)))
+			if i < height-1 || j < width-1 {
+				csv.WriteString(",")
+			}
+		}
+		csv.WriteString("\n")
+	}
+
+	m := tmxMap{
+		Version:      tiledVersion,
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        width,
+		Height:       height,
+		TileWidth:    g.Rules.ImageSize,
+		TileHeight:   g.Rules.ImageSize,
+		NextLayerId:  2,
+		NextObjectId: 1,
+		Tileset:      tmxTileset{FirstGid: 1, Source: tilesetSource},
+		Layer: tmxLayer{
+			Id:     1,
+			Name:   "Board",
+			Width:  width,
+			Height: height,
+			Data:   tmxData{Encoding: "csv", Csv: csv.String()},
+		},
+	}
+
+	return writeXML(w, m)
+}
+
+// tiledGid returns the gid of the card in a tileset with a firstgid of 1
+// empty cells, and cards with no image, are written as 0
+func (g *Game) tiledGid(card *Card) uint32 {
+	if card == nil || card.Base >= len(g.Rules.BaseCards) || g.Rules.BaseCards[card.Base].Filename == "" {
+		return 0
+	}
+
+	gid := uint32(card.Base + 1)
+	switch card.Rotation {
+	case 90:
+		gid |= tiledFlipDiagonal | tiledFlipHorizontal
+	case 180:
+		gid |= tiledFlipHorizontal | tiledFlipVertical
+	case 270:
+		gid |= tiledFlipDiagonal | tiledFlipVertical
+	}
+	return gid
+}
+
+func writeXML(w io.Writer, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
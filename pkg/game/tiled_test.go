@@ -0,0 +1,93 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_tiledGid(t *testing.T) {
+	g := getTestGame()
+	g.Rules.BaseCards = []BaseCards{
+		{Filename: "grass.png", Connectors: "GGGG"},
+		{Filename: "corner.png", Connectors: "RRGG", Rotations: []int{90, 180, 270}},
+		{Filename: "", Connectors: "RRRR"},
+	}
+
+	tests := []struct {
+		name string
+		card *Card
+		want uint32
+	}{
+		{"empty cell", nil, 0},
+		{"no rotation", &Card{Base: 0}, 1},
+		{"rotate 90", &Card{Base: 1, Rotation: 90}, 2 | tiledFlipDiagonal | tiledFlipHorizontal},
+		{"rotate 180", &Card{Base: 1, Rotation: 180}, 2 | tiledFlipHorizontal | tiledFlipVertical},
+		{"rotate 270", &Card{Base: 1, Rotation: 270}, 2 | tiledFlipDiagonal | tiledFlipVertical},
+		{"no image", &Card{Base: 2}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := g.tiledGid(tt.card)
+			if got != tt.want {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_WriteTMX(t *testing.T) {
+	g := getTestGame()
+	g.Rules.ImageSize = 32
+	g.Rules.BaseCards = []BaseCards{
+		{Filename: "grass.png", Connectors: "GGGG"},
+		{Filename: "cross.png", Connectors: "RRRR"},
+	}
+	g.Board[1][1].Card.Base = 1
+
+	var buf bytes.Buffer
+	err := g.WriteTMX(&buf, "wfc.tsx")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		`width="3" height="3" tilewidth="32" tileheight="32"`,
+		`<tileset firstgid="1" source="wfc.tsx"></tileset>`,
+		"\n0,0,0,\n0,2,0,\n0,0,0\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("tmx does not contain %q\n%s", want, got)
+		}
+	}
+}
+
+func Test_WriteTSX(t *testing.T) {
+	g := getTestGame()
+	g.Rules.ImageSize = 32
+	g.Rules.BaseCards = []BaseCards{
+		{Filename: "static/images/grass.png", Connectors: "GGGG"},
+		{Filename: "", Connectors: "RRRR"},
+		{Filename: "static/images/corner.png", Connectors: "RRGG"},
+	}
+
+	var buf bytes.Buffer
+	err := g.WriteTSX(&buf, "wfc")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		`name="wfc" tilewidth="32" tileheight="32" tilecount="2"`,
+		`<tile id="0">`,
+		`<image source="grass.png" width="32" height="32"></image>`,
+		`<tile id="2">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("tsx does not contain %q\n%s", want, got)
+		}
+	}
+}
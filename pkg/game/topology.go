@@ -0,0 +1,63 @@
+package game
+
+// hexOffsets are the steps to the neighbour on each side of a hex, in the same order as its connectors
+// the board holds the hexes in axial coordinates, the row is r and the column is q, which makes it a rhombus
+var hexOffsets = [6][2]int{{-1, 0}, {-1, 1}, {0, 1}, {1, 0}, {1, -1}, {0, -1}}
+
+var hexSideNames = []string{"N", "NE", "SE", "S", "SW", "NW"}
+
+var voxelSideNames = []string{"N", "E", "S", "W", "Up", "Down"}
+
+// Sides returns how many connectors a card has
+func (t Topology) Sides() int {
+	if t == Hex || t == Voxel {
+		return 6
+	}
+	return 4
+}
+
+// offsets returns the step to the neighbour on each side
+// voxels have a third dimension, see voxelOffsets, so only their first four sides are here
+func (t Topology) offsets() [][2]int {
+	if t == Hex {
+		return hexOffsets[:]
+	}
+	return sideOffsets[:]
+}
+
+// opposite returns the side of the neighbour that touches the side
+func (t Topology) opposite(side int) int {
+	if t == Voxel {
+		return voxelOpposite(side)
+	}
+	return (side + t.Sides()/2) % t.Sides()
+}
+
+func (t Topology) sideNames() []string {
+	switch t {
+	case Hex:
+		return hexSideNames
+	case Voxel:
+		return voxelSideNames
+	}
+	return sideNames
+}
+
+// rotationStep is the smallest rotation of a card, in degrees
+// voxels only turn around the vertical axis, so turn like squares
+func (t Topology) rotationStep() int {
+	if t == Voxel {
+		return 90
+	}
+	return 360 / t.Sides()
+}
+
+func (t Topology) String() string {
+	switch t {
+	case Hex:
+		return "hex"
+	case Voxel:
+		return "voxel"
+	}
+	return "square"
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrVoxelBoard is the error for voxel rules used to make a Game, whose board only has rows and columns
+var ErrVoxelBoard = errors.New("voxel rules can't be used for a board, they are generated with NewVoxels")
+
+// voxelOffsets are the steps to the neighbour on each face, as layer, row and column
+// in the same order as the connectors, north, east, south, west, up, down
+var voxelOffsets = [6][3]int{{0, -1, 0}, {0, 0, 1}, {0, 1, 0}, {0, 0, -1}, {1, 0, 0}, {-1, 0, 0}}
+
+// Voxels is a three dimensional board for rules with the Voxel topology
+// Cells is indexed [layer][row][column], layer 0 is the bottom, rows are BoardWidth and columns BoardHeight
+// the same as a Game's board, nil cells haven't had a card placed
+type Voxels struct {
+	Rules BasicRules
+	Cards map[int]*Card
+	Seed  uint64
+	Cells [][][]*Card
+	R     Rnd
+}
+
+// NewVoxels creates an empty set of voxels with the seed voxels placed
+func NewVoxels(rules BasicRules, cards map[int]*Card, seed uint64) *Voxels {
+	cells := make([][][]*Card, rules.BoardDepth)
+	for z := range cells {
+		cells[z] = make([][]*Card, rules.BoardWidth)
+		for i := range cells[z] {
+			cells[z][i] = make([]*Card, rules.BoardHeight)
+		}
+	}
+	for _, seed := range rules.SeedVoxels {
+		cells[seed.Z][seed.X][seed.Y] = cards[seed.Id]
+	}
+
+	return &Voxels{
+		Rules: rules,
+		Cards: cards,
+		Seed:  seed,
+		Cells: cells,
+		R:     NewSeed(seed),
+	}
+}
+
+// rotateVoxelConnections turns the card clockwise around the vertical axis
+// the up and down faces stay where they are
+func rotateVoxelConnections(connectors []Connector, rotation int) []Connector {
+	return append(rotateConnections(connectors[:4], rotation), connectors[4:]...)
+}
+
+// rotateVoxelCard is rotateCard for voxels, it turns the card around the vertical axis
+func rotateVoxelCard(card Card, rotation, id int) Card {
+	return Card{
+		Id: id,
+		Image: &Image{
+			img:         card.Image.img,
+			src:         card.Image.src,
+			rotateAngle: float64(rotation) * math.Pi / 180,
+		},
+		Connectors: rotateVoxelConnections(card.Connectors, rotation),
+		Base:       card.Base,
+		Rotation:   rotation,
+		chance:     card.chance,
+	}
+}
+
+// voxelOpposite returns the face of the neighbour that touches the face
+func voxelOpposite(side int) int {
+	if side < 4 {
+		return (side + 2) % 4
+	}
+	return 9 - side
+}
+
+// Generate places cards until every cell has one or no more cards fit
+// it works the same way as a Game, placing a card in one of the cells with the fewest cards that fit
+// and returns false if there are cells left empty
+func (v *Voxels) Generate() bool {
+	for v.evolve() {
+	}
+	return v.Complete()
+}
+
+// evolve places a single card, returning false if there are no cells that a card can be placed in
+func (v *Voxels) evolve() bool {
+	type voxelChoice struct {
+		z, i, j int
+		ids     []int
+	}
+
+	var lowest []voxelChoice
+	for z, layer := range v.Cells {
+		for i, row := range layer {
+			for j, card := range row {
+				if card != nil {
+					continue
+				}
+				ids := v.candidates(z, i, j)
+				if len(ids) == 0 {
+					continue
+				}
+				if len(lowest) == 0 || len(ids) < len(lowest[0].ids) {
+					lowest = lowest[:0]
+				}
+				if len(lowest) == 0 || len(ids) == len(lowest[0].ids) {
+					lowest = append(lowest, voxelChoice{z, i, j, ids})
+				}
+			}
+		}
+	}
+	if len(lowest) == 0 {
+		return false
+	}
+
+	selected := lowest[v.R.Intn(len(lowest))]
+	var id int
+	switch v.Rules.Randomiser {
+	case Basic:
+		id = selected.ids[v.R.Intn(len(selected.ids))]
+	case SimpleWeighted:
+		id = weightedPick(v.R, v.Cards, selected.ids)
+	}
+	v.Cells[selected.z][selected.i][selected.j] = v.Cards[id]
+	return true
+}
+
+// candidates returns the ids of the cards whose faces match the cards around the cell
+// faces on the outside of the board accept anything
+func (v *Voxels) candidates(z, i, j int) []int {
+	connectors := make([]Connector, 6)
+	for side, offset := range voxelOffsets {
+		connectors[side] = fullConnector
+		nz, ni, nj := z+offset[0], i+offset[1], j+offset[2]
+		if nz < 0 || nz >= len(v.Cells) || ni < 0 || ni >= len(v.Cells[nz]) || nj < 0 || nj >= len(v.Cells[nz][ni]) {
+			continue
+		}
+		if neighbour := v.Cells[nz][ni][nj]; neighbour != nil {
+			connectors[side] = neighbour.Connectors[voxelOpposite(side)]
+		}
+	}
+
+	var ids []int
+	for id := 1; id <= len(v.Cards); id++ {
+		if connectorsMatch(connectors, v.Cards[id].Connectors) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Complete returns true when every cell has a card
+func (v *Voxels) Complete() bool {
+	for _, layer := range v.Cells {
+		for _, row := range layer {
+			for _, card := range row {
+				if card == nil {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// VoxelExport is the json written by WriteJSON
+type VoxelExport struct {
+	Seed     uint64        `json:"seed"`
+	Width    int           `json:"width"`
+	Depth    int           `json:"depth"`
+	Height   int           `json:"height"`
+	Complete bool          `json:"complete"`
+	Voxels   []VoxelModule `json:"voxels"`
+}
+
+// VoxelModule is a placed card, x is east, y is south and z is up
+// Module is the file of the base card, which a building generator can use to pick the model to place
+type VoxelModule struct {
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Z          int    `json:"z"`
+	Id         int    `json:"id"`
+	Base       int    `json:"base"`
+	Rotation   int    `json:"rotation"`
+	Connectors string `json:"connectors"`
+	Module     string `json:"module"`
+}
+
+// Export lists the placed cards, cells without a card are left out
+func (v *Voxels) Export() VoxelExport {
+	export := VoxelExport{
+		Seed:     v.Seed,
+		Width:    v.Rules.BoardHeight,
+		Depth:    v.Rules.BoardWidth,
+		Height:   v.Rules.BoardDepth,
+		Complete: v.Complete(),
+		Voxels:   []VoxelModule{},
+	}
+	for z, layer := range v.Cells {
+		for i, row := range layer {
+			for j, card := range row {
+				if card == nil {
+					continue
+				}
+				export.Voxels = append(export.Voxels, VoxelModule{
+					X:          j,
+					Y:          i,
+					Z:          z,
+					Id:         card.Id,
+					Base:       card.Base,
+					Rotation:   card.Rotation,
+					Connectors: card.ConnectorString(),
+					Module:     v.Rules.BaseCards[card.Base].Filename,
+				})
+			}
+		}
+	}
+	return export
+}
+
+// WriteJSON writes the placed cards as json
+func (v *Voxels) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v.Export())
+}
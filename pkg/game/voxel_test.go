@@ -0,0 +1,180 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func getVoxelRules() BasicRules {
+	return BasicRules{
+		ImageSize:   32,
+		BoardWidth:  4,
+		BoardHeight: 5,
+		BoardDepth:  3,
+		Topology:    Voxel,
+		BaseCards: []BaseCards{
+			{Connectors: "GGGGGG", Chance: 10},
+			{Connectors: "GGGGRR", Chance: 10},
+			{Connectors: "RGRGGG", Rotations: []int{90}, Chance: 10},
+			{Connectors: "RRGGGR", Rotations: []int{90, 180, 270}, Chance: 10},
+		},
+	}
+}
+
+func Test_rotateVoxelConnections(t *testing.T) {
+	got := rotateVoxelConnections(convertConnections("RRGGGR"), 90)
+	compareConnectors(t, got, convertConnections("GRRGGR"))
+}
+
+func Test_ValidateVoxel(t *testing.T) {
+	rules := getVoxelRules()
+	rules.Wrap = WrapHorizontal
+	rules.Borders.North = BorderRule{Connector: "G"}
+	rules.Mask = []string{"#"}
+	err := rules.Validate(getFS())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{"wrap", "borders", "masks"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func Test_VoxelCardRotation(t *testing.T) {
+	rules := getVoxelRules()
+	cards := BuildCards(rules, getFS())
+	// the corner with a road up, turned 90, 180 and 270
+	for id, want := range map[int]string{5: "RRGGGR", 6: "GRRGGR", 7: "GGRRGR", 8: "RGGRGR"} {
+		compareConnectors(t, cards[id].Connectors, convertConnections(want))
+	}
+}
+
+func Test_VoxelsGenerate(t *testing.T) {
+	fs := getFS()
+	rules := getVoxelRules()
+	if err := rules.Validate(fs); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	v := NewVoxels(rules, BuildCards(rules, fs), 9)
+	v.Generate()
+
+	placed := 0
+	for z, layer := range v.Cells {
+		for i, row := range layer {
+			for j, card := range row {
+				if card == nil {
+					continue
+				}
+				placed++
+				for side, offset := range voxelOffsets {
+					nz, ni, nj := z+offset[0], i+offset[1], j+offset[2]
+					if nz < 0 || nz >= 3 || ni < 0 || ni >= 4 || nj < 0 || nj >= 5 || v.Cells[nz][ni][nj] == nil {
+						continue
+					}
+					other := v.Cells[nz][ni][nj]
+					if card.Connectors[side] != other.Connectors[voxelOpposite(side)] {
+						t.Errorf("card %d at (%d, %d, %d) doesn't match card %d on face %s", card.Id, z, i, j, other.Id, voxelSideNames[side])
+					}
+				}
+			}
+		}
+	}
+	if placed == 0 {
+		t.Fatalf("nothing placed")
+	}
+
+	var buf bytes.Buffer
+	if err := v.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var export VoxelExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("export isn't json: %v", err)
+	}
+	if len(export.Voxels) != placed || export.Width != 5 || export.Depth != 4 || export.Height != 3 {
+		t.Errorf("export got %d voxels in %dx%dx%d, want %d in 5x4x3", len(export.Voxels), export.Width, export.Depth, export.Height, placed)
+	}
+}
+
+func Test_VoxelsSeedVoxels(t *testing.T) {
+	fs := getFS()
+	rules := getVoxelRules()
+	// a pillar in the middle of the top layer
+	rules.SeedVoxels = []SeedVoxels{{X: 1, Y: 2, Z: 2, Id: 2}}
+	v := NewVoxels(rules, BuildCards(rules, fs), 1)
+	v.Generate()
+
+	if v.Cells[2][1][2] == nil || v.Cells[2][1][2].Id != 2 {
+		t.Fatalf("seed tile not kept")
+	}
+	if below := v.Cells[1][1][2]; below != nil && below.Connectors[4] != Road {
+		t.Errorf("card %d below the pillar doesn't connect up to it", below.Id)
+	}
+
+	rules.SeedVoxels = []SeedVoxels{{X: 1, Y: 2, Z: 3, Id: 2}}
+	if err := rules.Validate(fs); err == nil {
+		t.Errorf("expected seed tile above the top layer to be invalid")
+	}
+}
+
+func Test_VoxelOpposite(t *testing.T) {
+	for side := range voxelOffsets {
+		if got := Voxel.opposite(side); got != voxelOpposite(side) {
+			t.Errorf("opposite of %s got %s, want %s", voxelSideNames[side], voxelSideNames[got], voxelSideNames[voxelOpposite(side)])
+		}
+	}
+}
+
+func Test_VoxelRulesOnBoard(t *testing.T) {
+	rules := getVoxelRules()
+	cards := BuildCards(rules, getFS())
+
+	t.Run("new game panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r != ErrVoxelBoard {
+				t.Errorf("got %v, want a panic with %v", r, ErrVoxelBoard)
+			}
+		}()
+		NewGameWithRules(getFS(), rules, cards, 1)
+	})
+
+	t.Run("reload keeps the previous rules", func(t *testing.T) {
+		fsys := getFS().(fstest.MapFS)
+		fsys[DefaultRulesFile].ModTime = time.Unix(1000, 0)
+		square := LoadRules(DefaultRulesFile, fsys)
+		square.SeedTiles = nil
+		g := NewGameWithRules(fsys, square, BuildCards(square, fsys), 1)
+		g.WatchFiles()
+
+		var buf bytes.Buffer
+		if err := WriteRules(&buf, rules); err != nil {
+			t.Fatal(err)
+		}
+		fsys[DefaultRulesFile] = &fstest.MapFile{Data: buf.Bytes(), ModTime: time.Unix(2000, 0)}
+		if g.checkFiles() {
+			t.Errorf("reloaded voxel rules")
+		}
+		if _, _, err := reloadRules(fsys); !errors.Is(err, ErrVoxelBoard) {
+			t.Errorf("got error %v, want %v", err, ErrVoxelBoard)
+		}
+	})
+
+	t.Run("editor rejects them", func(t *testing.T) {
+		fsys := getFS()
+		square := LoadRules(DefaultRulesFile, fsys)
+		square.SeedTiles = nil
+		g := NewGameWithRules(fsys, square, BuildCards(square, fsys), 1)
+		g.editor = editor{active: true, rules: rules}
+		g.applyEditor()
+		if g.Rules.Topology != Square || g.status == "" {
+			t.Errorf("applied voxel rules, status %q", g.status)
+		}
+	})
+}
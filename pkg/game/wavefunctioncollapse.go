@@ -1,6 +1,10 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"slices"
+)
 
 const fullConnector = Grass + Road
 
@@ -9,6 +13,19 @@ var initialBuildCell = buildCell{placed: false, connectors: []Connector{Grass +
 type buildCell struct {
 	placed     bool
 	connectors []Connector
+	id         int // the id of the card placed, 0 for none or for a cell that is only its connectors
+}
+
+// emptyCell returns a build cell with nothing placed in it, allowing any connector on each side
+func (g *Game) emptyCell() buildCell {
+	if g.Rules.Topology == Square {
+		return initialBuildCell
+	}
+	connectors := make([]Connector, g.Rules.Topology.Sides())
+	for k := range connectors {
+		connectors[k] = fullConnector
+	}
+	return buildCell{connectors: connectors}
 }
 
 type available struct {
@@ -24,9 +41,11 @@ func getBuildBoard(g *Game) [][]buildCell {
 		for j := range board[i] {
 			if g.Board[i][j].Card != nil {
 				// if the cell is already placed
-				board[i][j] = buildCell{placed: true, connectors: g.Board[i][j].Card.Connectors}
+				board[i][j] = buildCell{placed: true, connectors: g.Board[i][j].Card.Connectors, id: g.Board[i][j].Card.Id}
+			} else if g.Masked(i, j) {
+				board[i][j] = g.maskedCell()
 			} else {
-				board[i][j] = initialBuildCell
+				board[i][j] = g.emptyCell()
 			}
 		}
 	}
@@ -45,25 +64,8 @@ func getEntropyBoard(board [][]buildCell, g *Game) [][][]int {
 		entropyBoard[i] = make([][]int, len(row))
 
 		for j, cell := range row {
-			placed := cell.placed
-			buildCell := buildCell{connectors: getEntropicCard(board, i, j)}
-
-			if !placed {
-				// compare the built cell to all of the cards
-				for l := 1; l <= len(g.Cards); l++ {
-					card := g.Cards[l]
-					match := true
-					for k := 0; k < 4; k++ {
-						if (buildCell.connectors[k] & card.Connectors[k]) == 0 {
-							match = false
-							break
-						}
-
-					}
-					if match {
-						entropyBoard[i][j] = append(entropyBoard[i][j], card.Id)
-					}
-				}
+			if !cell.placed {
+				entropyBoard[i][j] = g.candidates(board, i, j)
 			} else {
 				entropyBoard[i][j] = []int{}
 			}
@@ -75,24 +77,134 @@ func getEntropyBoard(board [][]buildCell, g *Game) [][][]int {
 
 }
 
+// candidates returns the ids of the cards whose connectors match the cells surrounding (i, j)
+func (g *Game) candidates(board [][]buildCell, i, j int) []int {
+	return g.allowedNeighbours(board, i, j, g.matchingCards(g.entropicCard(board, i, j)))
+}
+
+// matchingCards returns the ids of the cards that fit a cell needing the connectors
+func (g *Game) matchingCards(connectors []Connector) []int {
+	var ids []int
+	// compare the built cell to all of the cards
+	for l := 1; l <= len(g.Cards); l++ {
+		card := g.Cards[l]
+		if connectorsMatch(connectors, card.Connectors) {
+			ids = append(ids, card.Id)
+		}
+	}
+	return ids
+}
+
+// connectorsMatch reports whether a card's connectors share a connector with each side of the cell
+func connectorsMatch(cell, card []Connector) bool {
+	for k := range cell {
+		if (cell[k] & card[k]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func getEntropicCard(board [][]buildCell, i, j int) []Connector {
-	n, e, s, w := fullConnector, fullConnector, fullConnector, fullConnector
-	row := len(board[0])
+	return rulesEntropicCard(board, i, j, BasicRules{}, [4][]Connector{})
+}
+
+// entropicCard is getEntropicCard, with the neighbours wrapping around the edges the rules join
+// and the border rules, or the game's edges, applied to the edges that don't
+func (g *Game) entropicCard(board [][]buildCell, i, j int) []Connector {
+	return rulesEntropicCard(board, i, j, g.Rules, g.edges)
+}
 
-	if i > 0 {
-		n = board[i-1][j].connectors[2]
+// rulesEntropicCard returns the connectors a card at (i, j) needs to match its neighbours
+// sides off the edge of the board need the connector from the edges if there are any for that edge,
+// otherwise from the border rule
+func rulesEntropicCard(board [][]buildCell, i, j int, rules BasicRules, edges [4][]Connector) []Connector {
+	rows, columns := len(board), len(board[0])
+	connectors := make([]Connector, rules.Topology.Sides())
+	for side, offset := range rules.Topology.offsets() {
+		ni, nj, ok := neighbour(rows, columns, i, j, offset, rules.Wrap)
+		if ok {
+			connectors[side] = board[ni][nj].connectors[rules.Topology.opposite(side)]
+			continue
+		}
+		edge, position := boardEdge(rows, i, j, offset, rules.Wrap)
+		if edges[edge] != nil {
+			connectors[side] = edges[edge][position]
+			continue
+		}
+		connectors[side] = rules.Borders.connector(edge, position)
 	}
-	if j < row-1 {
-		e = board[i][j+1].connectors[3]
+	return connectors
+}
+
+// boardEdge returns which edge of the board the step from (i, j) goes off, north, east, south or west,
+// and the position of (i, j) along it, the column for the north and south edges and the row for the others
+func boardEdge(rows, i, j int, offset [2]int, wrap Wrap) (edge, position int) {
+	ni := i + offset[0]
+	if wrap&WrapVertical == 0 {
+		if ni < 0 {
+			return 0, j
+		}
+		if ni >= rows {
+			return 2, j
+		}
 	}
-	if i < len(board)-1 {
-		s = board[i+1][j].connectors[0]
+	if offset[1] > 0 {
+		return 1, i
 	}
-	if j > 0 {
-		w = board[i][j-1].connectors[1]
+	return 3, i
+}
+
+// side returns the border rule for the side, in the same order as the connectors
+func (b Borders) side(side int) BorderRule {
+	return [4]BorderRule{b.North, b.East, b.South, b.West}[side]
+}
+
+// empty reports whether none of the sides have a border rule
+func (b Borders) empty() bool {
+	for side := 0; side < 4; side++ {
+		if rule := b.side(side); rule.Connector != "" || len(rule.Exits) > 0 {
+			return false
+		}
 	}
-	return []Connector{n, e, s, w}
+	return true
+}
 
+// connector returns the connectors allowed at the position along the side of the board
+func (b Borders) connector(side, position int) Connector {
+	rule := b.side(side)
+	if slices.Contains(rule.Exits, position) {
+		return Road
+	}
+	switch rule.Connector {
+	case "G":
+		return Grass
+	case "R":
+		return Road
+	}
+	return fullConnector
+}
+
+// sideOffsets are the steps to the neighbour on each side, north, east, south, west
+var sideOffsets = [4][2]int{{-1, 0}, {0, 1}, {1, 0}, {0, -1}}
+
+// neighbour returns the position a step of offset from (i, j) on a rows by columns board
+// ok is false if it is off an edge that doesn't wrap
+func neighbour(rows, columns, i, j int, offset [2]int, wrap Wrap) (int, int, bool) {
+	ni, nj := i+offset[0], j+offset[1]
+	if ni < 0 || ni >= rows {
+		if wrap&WrapVertical == 0 {
+			return 0, 0, false
+		}
+		ni = (ni + rows) % rows
+	}
+	if nj < 0 || nj >= columns {
+		if wrap&WrapHorizontal == 0 {
+			return 0, 0, false
+		}
+		nj = (nj + columns) % columns
+	}
+	return ni, nj, true
 }
 
 // return a list of the locations that have the fewest possible cards
@@ -113,17 +225,27 @@ func countEntropyBoard(entropyBoard [][][]int) []available {
 	return availableCells
 }
 
-func (g *Game) evolveBoard(buildBoard *[][]buildCell) bool {
+// choice is a card picked to be placed at a location on the board
+type choice struct {
+	x  int
+	y  int
+	id int
+}
 
-	// the whole loop
+// nextChoice picks the location and card that the next evolveBoard call will place
+// the pick is kept until it is placed, so looking at it doesn't change how the board generates
+func (g *Game) nextChoice(buildBoard [][]buildCell) (choice, bool) {
+	if g.pending != nil {
+		return *g.pending, true
+	}
 
-	entropyBoard := getEntropyBoard(*buildBoard, g)
+	entropyBoard := getEntropyBoard(buildBoard, g)
 
 	// get a list of the lowest entropy
 	availableCards := countEntropyBoard(entropyBoard)
 
 	if len(availableCards) == 0 {
-		return false
+		return choice{}, false
 	}
 
 	// randomisation functionality
@@ -133,36 +255,65 @@ func (g *Game) evolveBoard(buildBoard *[][]buildCell) bool {
 
 	// select a random id from the available
 	var selectedCardId int
-	switch g.Rules.Randomiser {
-	case Basic:
-		selectedCardId = selectedAvaialable.ids[g.R.Intn(len(selectedAvaialable.ids))]
-	case SimpleWeighted:
-		selectedCardId = basicWeightedRandom(g, selectedAvaialable.ids)
+	if g.Rules.ConnectedRoads {
+		var connected bool
+		selectedCardId, connected = g.connectedCard(buildBoard, selectedAvaialable.x, selectedAvaialable.y, selectedAvaialable.ids)
+		g.roadsSplit = g.roadsSplit || !connected
+	} else {
+		selectedCardId = g.pickCard(selectedAvaialable.ids)
+	}
+
+	g.pending = &choice{x: selectedAvaialable.x, y: selectedAvaialable.y, id: selectedCardId}
+	return *g.pending, true
+}
+
+func (g *Game) evolveBoard(buildBoard *[][]buildCell) bool {
+
+	// the whole loop
+
+	selected, ok := g.nextChoice(*buildBoard)
+	if !ok {
+		return false
 	}
+	g.pending = nil
 
 	// place the card in the buildBoard
-	(*buildBoard)[selectedAvaialable.x][selectedAvaialable.y] = buildCell{placed: true, connectors: g.Cards[selectedCardId].Connectors}
+	(*buildBoard)[selected.x][selected.y] = buildCell{placed: true, connectors: g.Cards[selected.id].Connectors, id: selected.id}
 
 	// place the card on the board
-	g.Board[selectedAvaialable.x][selectedAvaialable.y] = Tile{Card: g.Cards[selectedCardId], X: selectedAvaialable.x, Y: selectedAvaialable.y}
+	g.Board[selected.x][selected.y] = Tile{Card: g.Cards[selected.id], X: selected.x, Y: selected.y}
 
 	return true
 }
 
+// pickCard picks one of the ids at random, using the rules' randomiser
+func (g *Game) pickCard(ids []int) int {
+	switch g.Rules.Randomiser {
+	case SimpleWeighted:
+		return basicWeightedRandom(g, ids)
+	}
+	return ids[g.R.Intn(len(ids))]
+}
+
 func basicWeightedRandom(g *Game, ids []int) int {
+	return weightedPick(g.R, g.Cards, ids)
+}
+
+// weightedPick picks one of the ids at random, using the card chance as the weight
+func weightedPick(rnd Rnd, cards map[int]*Card, ids []int) int {
 	// get the total weight of the cards
 	totalWeight := 0
 	for _, id := range ids {
-		totalWeight += g.Cards[id].chance
+		totalWeight += cards[id].chance
 	}
 
 	// get a random number between 0 and the total weight
-	r := g.R.Intn(totalWeight)
+	r := rnd.Intn(totalWeight)
 
 	// loop through the ids and subtract the weight from the random number until it is less than 0
 	// then return that id
 	for _, id := range ids {
-		r -= g.Cards[id].chance
+		r -= cards[id].chance
 		if r < 0 {
 			return id
 		}
@@ -172,6 +323,24 @@ func basicWeightedRandom(g *Game, ids []int) int {
 	return ids[0]
 }
 
+// weightedEntropy returns the shannon entropy of the cards, using the card chance as the weight
+func weightedEntropy(g *Game, ids []int) float64 {
+	total := 0.0
+	sumWeightLogWeight := 0.0
+	for _, id := range ids {
+		w := float64(g.Cards[id].chance)
+		if w <= 0 {
+			continue
+		}
+		total += w
+		sumWeightLogWeight += w * math.Log(w)
+	}
+	if total == 0 {
+		return 0
+	}
+	return math.Log(total) - sumWeightLogWeight/total
+}
+
 func debugPrintEntropyBoard(title string, entropyBoard [][][]int) {
 	fmt.Println(title)
 	for _, row := range entropyBoard {
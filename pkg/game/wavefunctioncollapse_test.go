@@ -2,6 +2,7 @@ package game
 
 import (
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -222,6 +223,122 @@ func Test_getEntropicCard(t *testing.T) {
 
 }
 
+func Test_entropicCardWrap(t *testing.T) {
+	rules := getBasicRules()
+	rules.SeedTiles = []SeedTiles{{0, 0, 2}}
+
+	tests := []struct {
+		wrap    Wrap
+		i, j    int
+		want    []Connector
+		comment string
+	}{
+		{WrapNone, 2, 0, []Connector{Grass + Road, Grass + Road, Grass + Road, Grass + Road}, "no wrap bottom left"},
+		{WrapVertical, 2, 0, []Connector{Grass + Road, Grass + Road, Road, Grass + Road}, "vertical wrap bottom left"},
+		{WrapVertical, 0, 2, []Connector{Grass + Road, Grass + Road, Grass + Road, Grass + Road}, "vertical wrap top right"},
+		{WrapHorizontal, 0, 2, []Connector{Grass + Road, Road, Grass + Road, Grass + Road}, "horizontal wrap top right"},
+		{WrapHorizontal, 2, 0, []Connector{Grass + Road, Grass + Road, Grass + Road, Grass + Road}, "horizontal wrap bottom left"},
+		{WrapBoth, 2, 0, []Connector{Grass + Road, Grass + Road, Road, Grass + Road}, "both wrap bottom left"},
+		{WrapBoth, 0, 2, []Connector{Grass + Road, Road, Grass + Road, Grass + Road}, "both wrap top right"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			rules.Wrap = tt.wrap
+			g := getTestGameWithRules(rules)
+			got := g.entropicCard(getBuildBoard(g), tt.i, tt.j)
+			compareConnectors(t, got, tt.want)
+		})
+	}
+}
+
+func Test_GenerateWrapped(t *testing.T) {
+	fs := getFS()
+	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules.SeedTiles = nil
+	rules.Wrap = WrapBoth
+	g := NewGameWithRules(fs, rules, BuildCards(rules, fs), 7)
+	if !g.Generate() {
+		t.Fatalf("wrapped board didn't generate")
+	}
+
+	// every pair of placed cards across the joined edges must match
+	rows, columns := len(g.Board), len(g.Board[0])
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			for side := 0; side < 4; side++ {
+				ni, nj, _ := neighbour(rows, columns, i, j, sideOffsets[side], WrapBoth)
+				card, other := g.Board[i][j].Card, g.Board[ni][nj].Card
+				if card == nil || other == nil {
+					continue
+				}
+				if card.Connectors[side] != other.Connectors[(side+2)%4] {
+					t.Errorf("card %d at (%d, %d) doesn't match card %d at (%d, %d) on side %d", card.Id, i, j, other.Id, ni, nj, side)
+				}
+			}
+		}
+	}
+}
+
+func Test_entropicCardBorders(t *testing.T) {
+	rules := getBasicRules()
+	rules.SeedTiles = nil
+	rules.Borders = Borders{
+		North: BorderRule{Connector: "G"},
+		East:  BorderRule{Connector: "R"},
+		West:  BorderRule{Connector: "G", Exits: []int{1}},
+	}
+	g := getTestGameWithRules(rules)
+	board := getBuildBoard(g)
+
+	tests := []struct {
+		i, j int
+		want []Connector
+	}{
+		{0, 0, []Connector{Grass, Grass + Road, Grass + Road, Grass}},
+		{0, 2, []Connector{Grass, Road, Grass + Road, Grass + Road}},
+		{1, 0, []Connector{Grass + Road, Grass + Road, Grass + Road, Road}},
+		{2, 1, []Connector{Grass + Road, Grass + Road, Grass + Road, Grass + Road}},
+	}
+	for _, tt := range tests {
+		got := g.entropicCard(board, tt.i, tt.j)
+		compareConnectors(t, got, tt.want)
+	}
+}
+
+func Test_GenerateBorders(t *testing.T) {
+	fs := getFS()
+	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules.SeedTiles = nil
+	grass := BorderRule{Connector: "G"}
+	rules.Borders = Borders{North: grass, East: grass, South: grass, West: BorderRule{Connector: "G", Exits: []int{4}}}
+	g := NewGameWithRules(fs, rules, BuildCards(rules, fs), 7)
+	if !g.Generate() {
+		t.Fatalf("board with borders didn't generate")
+	}
+
+	rows, columns := len(g.Board), len(g.Board[0])
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			card := g.Board[i][j].Card
+			if card == nil {
+				continue
+			}
+			if i == 0 && card.Connectors[0] != Grass {
+				t.Errorf("card %d at (%d, %d) has a road off the north edge", card.Id, i, j)
+			}
+			if i == rows-1 && card.Connectors[2] != Grass {
+				t.Errorf("card %d at (%d, %d) has a road off the south edge", card.Id, i, j)
+			}
+			if j == columns-1 && card.Connectors[1] != Grass {
+				t.Errorf("card %d at (%d, %d) has a road off the east edge", card.Id, i, j)
+			}
+			if j == 0 && (card.Connectors[3] == Road) != (i == 4) {
+				t.Errorf("card %d at (%d, %d) has west connector %s, the only exit is at row 4", card.Id, i, j, card.Connectors[3])
+			}
+		}
+	}
+}
+
 func Test_countEntropyBoard(t *testing.T) {
 
 	t.Run("Test the actual entropy board", func(t *testing.T) {
@@ -608,3 +725,62 @@ func printBoard(board [][]Tile) {
 		println()
 	}
 }
+
+func Test_nextChoice(t *testing.T) {
+	// peeking at the next choice must not change the board that gets generated
+	peeked := getTestGame()
+	peekedBoard := getBuildBoard(peeked)
+	plain := getTestGame()
+	plainBoard := getBuildBoard(plain)
+
+	for {
+		next, ok := peeked.nextChoice(peekedBoard)
+		again, _ := peeked.nextChoice(peekedBoard)
+		if next != again {
+			t.Fatalf("peeking twice gave different choices %v and %v", next, again)
+		}
+
+		evolved := peeked.evolveBoard(&peekedBoard)
+		if evolved != ok {
+			t.Fatalf("next choice said %v but evolve said %v", ok, evolved)
+		}
+		plain.evolveBoard(&plainBoard)
+		if !evolved {
+			break
+		}
+		if peeked.Board[next.x][next.y].Card.Id != next.id {
+			t.Errorf("placed %v, but the next choice was %v", peeked.Board[next.x][next.y].Card, next)
+		}
+	}
+
+	for i, row := range plain.Board {
+		for j, tile := range row {
+			got := peeked.Board[i][j].Card
+			if (tile.Card == nil) != (got == nil) || (got != nil && tile.Card.Id != got.Id) {
+				t.Errorf("boards differ at (%d, %d)", i, j)
+			}
+		}
+	}
+}
+
+func Test_weightedEntropy(t *testing.T) {
+	g := getTestGame()
+	for _, card := range g.Cards {
+		card.chance = 10
+	}
+
+	if got := weightedEntropy(g, []int{1}); got != 0 {
+		t.Errorf("single card should have no entropy, got %f", got)
+	}
+
+	// equal weights give the log of the number of cards
+	if got, want := weightedEntropy(g, []int{1, 2, 3, 4}), math.Log(4); math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %f, want %f", got, want)
+	}
+
+	// an unlikely card adds less entropy than an equally likely one
+	g.Cards[2].chance = 1
+	if weightedEntropy(g, []int{1, 2}) >= math.Log(2) {
+		t.Errorf("uneven weights should have less entropy than even ones")
+	}
+}
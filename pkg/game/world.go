@@ -0,0 +1,237 @@
+package game
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// ChunkAttempts is how many seeds a chunk is tried with before it is left incomplete
+const ChunkAttempts = 10
+
+// World is an endless map made of chunks, each a board the size of the rules
+// chunks are generated when they are first asked for, matching the edges of the chunks around them
+// chunk (x, y) is x chunks east and y chunks south of chunk (0, 0)
+type World struct {
+	Fs    fs.FS
+	Rules BasicRules
+	Cards map[int]*Card
+	Seed  uint64
+
+	// FixedBorders decides the connectors along every chunk edge from the world seed and the edge's position
+	// before filling the chunk, so a chunk is always the same whichever order the world is explored in
+	// otherwise a chunk's edges come from the neighbouring chunks that were generated before it
+	FixedBorders bool
+
+	chunks map[ChunkPos]*Chunk
+}
+
+type ChunkPos struct {
+	X int
+	Y int
+}
+
+// Chunk is a generated part of the world, the Game holds its board and the seed it was generated with
+type Chunk struct {
+	ChunkPos
+	Game     *Game
+	Complete bool
+}
+
+// NewWorld creates an empty world, the seed tiles, wrap and border rules are ignored
+// as the edges of each chunk come from its neighbours, it returns an error unless the rules have square cells
+func NewWorld(fs fs.FS, rules BasicRules, cards map[int]*Card, seed uint64) (*World, error) {
+	if rules.Topology != Square {
+		return nil, fmt.Errorf("can't make a world of %s cards, the chunk edges need square cells", rules.Topology)
+	}
+	rules.SeedTiles = nil
+	rules.Wrap = WrapNone
+	rules.Borders = Borders{}
+
+	return &World{
+		Fs:     fs,
+		Rules:  rules,
+		Cards:  cards,
+		Seed:   seed,
+		chunks: make(map[ChunkPos]*Chunk),
+	}, nil
+}
+
+// Chunk returns the chunk at (x, y), generating it if it hasn't been already
+// the chunk's seed comes from the world seed and its position, so the same world explored
+// in the same order always has the same chunks, in any order if the world has FixedBorders
+func (w *World) Chunk(x, y int) *Chunk {
+	pos := ChunkPos{x, y}
+	if chunk, ok := w.chunks[pos]; ok {
+		return chunk
+	}
+
+	var edges [4][]Connector
+	if w.FixedBorders {
+		edges = w.fixedEdges(pos)
+	} else {
+		edges = w.neighbourEdges(pos)
+	}
+
+	var g *Game
+	complete := false
+	for attempt := 0; attempt < ChunkAttempts && !complete; attempt++ {
+		g = NewGameWithRules(w.Fs, w.Rules, w.Cards, chunkSeed(w.Seed, x, y, attempt))
+		g.edges = edges
+		complete = g.Generate()
+	}
+
+	chunk := &Chunk{ChunkPos: pos, Game: g, Complete: complete}
+	w.chunks[pos] = chunk
+	return chunk
+}
+
+// Generated reports whether the chunk at (x, y) has been generated
+func (w *World) Generated(x, y int) bool {
+	_, ok := w.chunks[ChunkPos{x, y}]
+	return ok
+}
+
+// Card returns the card in the row and column of the whole world, generating its chunk if needed
+func (w *World) Card(row, column int) *Card {
+	rows, columns := w.Rules.BoardWidth, w.Rules.BoardHeight
+	chunk := w.Chunk(floorDiv(column, columns), floorDiv(row, rows))
+	return chunk.Game.Board[row-chunk.Y*rows][column-chunk.X*columns].Card
+}
+
+// neighbourEdges returns the connectors needed along each edge of the chunk to match
+// the chunks already generated around it, nil for the sides without one
+func (w *World) neighbourEdges(pos ChunkPos) [4][]Connector {
+	var edges [4][]Connector
+	for side, offset := range sideOffsets {
+		// the side offsets are in rows and columns, chunks are x and y
+		neighbour, ok := w.chunks[ChunkPos{pos.X + offset[1], pos.Y + offset[0]}]
+		if ok {
+			edges[side] = edgeConnectors(neighbour.Game.Board, (side+2)%4)
+		}
+	}
+	return edges
+}
+
+// fixedEdges returns the connectors along each edge of the chunk, decided by the world seed alone
+// a chunk's north edge is the same as the south edge of the chunk above, and its west edge the
+// same as the east edge of the chunk to the left
+func (w *World) fixedEdges(pos ChunkPos) [4][]Connector {
+	rows, columns := w.Rules.BoardWidth, w.Rules.BoardHeight
+	roads := roadFraction(w.Cards, w.Rules.Randomiser)
+
+	// horizontal edges are keyed by the chunk below them, vertical edges by the chunk to their right
+	const horizontal, vertical = 0, 1
+	keys := [4][3]int{
+		{horizontal, pos.X, pos.Y},
+		{vertical, pos.X + 1, pos.Y},
+		{horizontal, pos.X, pos.Y + 1},
+		{vertical, pos.X, pos.Y},
+	}
+
+	var edges [4][]Connector
+	for side, key := range keys {
+		length := columns
+		if side == 1 || side == 3 {
+			length = rows
+		}
+		edges[side] = make([]Connector, length)
+		for p := range edges[side] {
+			edges[side][p] = Grass
+			if unitFloat(mix(w.Seed, key[0], key[1], key[2], p)) < roads {
+				edges[side][p] = Road
+			}
+		}
+	}
+	return edges
+}
+
+// roadFraction is how likely a connector is to be a road, weighting each card the way the randomiser does
+// so the fixed chunk edges have about as many roads as the cards would place
+func roadFraction(cards map[int]*Card, randomiser Randomiser) float64 {
+	total, roads := 0.0, 0.0
+	for _, card := range cards {
+		weight := 1.0
+		if randomiser == SimpleWeighted {
+			weight = float64(card.chance)
+		}
+		for _, connector := range card.Connectors {
+			total += weight
+			if connector == Road {
+				roads += weight
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return roads / total
+}
+
+// edgeConnectors returns the connectors facing out of the side of the board, from the north or west end
+// cells without a card allow any connector
+func edgeConnectors(board [][]Tile, side int) []Connector {
+	rows, columns := len(board), len(board[0])
+	var connectors []Connector
+	switch side {
+	case 0, 2:
+		i := 0
+		if side == 2 {
+			i = rows - 1
+		}
+		for j := 0; j < columns; j++ {
+			connectors = append(connectors, tileConnector(board[i][j], side))
+		}
+	case 1, 3:
+		j := 0
+		if side == 1 {
+			j = columns - 1
+		}
+		for i := 0; i < rows; i++ {
+			connectors = append(connectors, tileConnector(board[i][j], side))
+		}
+	}
+	return connectors
+}
+
+func tileConnector(tile Tile, side int) Connector {
+	if tile.Card == nil {
+		return fullConnector
+	}
+	return tile.Card.Connectors[side]
+}
+
+// chunkSeed mixes the world seed with the chunk position and attempt
+// so that neighbouring chunks get unrelated seeds
+func chunkSeed(seed uint64, x, y, attempt int) uint64 {
+	return mix(seed, x, y, attempt)
+}
+
+// mix hashes the values into the seed using splitmix64
+func mix(seed uint64, values ...int) uint64 {
+	h := seed
+	for _, v := range values {
+		h = splitmix64(h ^ uint64(int64(v)))
+	}
+	return h
+}
+
+// unitFloat turns a hash into a number in [0, 1)
+func unitFloat(h uint64) float64 {
+	return float64(h>>11) / (1 << 53)
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// floorDiv divides rounding towards negative infinity, so that -1 is in chunk -1 rather than 0
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
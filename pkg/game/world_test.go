@@ -0,0 +1,162 @@
+package game
+
+import "testing"
+
+func getTestWorld(seed uint64) *World {
+	fs := getFS()
+	rules := LoadRules("static/rules/basicRules.json", fs)
+	rules.BoardWidth, rules.BoardHeight = 6, 8
+	w, err := NewWorld(fs, rules, BuildCards(rules, fs), seed)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+func Test_NewWorldTopology(t *testing.T) {
+	for _, rules := range []BasicRules{getHexRules(), getVoxelRules()} {
+		_, err := NewWorld(getFS(), rules, BuildCards(rules, getFS()), 1)
+		if err == nil {
+			t.Errorf("made a world of %s cards", rules.Topology)
+		}
+	}
+}
+
+func Test_WorldChunkEdges(t *testing.T) {
+	w := getTestWorld(3)
+
+	// explore outwards in a spiral so chunks have neighbours on several sides
+	for _, pos := range []ChunkPos{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}} {
+		w.Chunk(pos.X, pos.Y)
+	}
+
+	// every pair of cards next to each other in the world must match, including across chunks
+	for row := -6; row < 12; row++ {
+		for column := -8; column < 16; column++ {
+			card := w.Card(row, column)
+			if card == nil {
+				continue
+			}
+			if east := w.Card(row, column+1); column < 15 && east != nil && card.Connectors[1] != east.Connectors[3] {
+				t.Errorf("card %d at (%d, %d) doesn't match card %d to the east", card.Id, row, column, east.Id)
+			}
+			if south := w.Card(row+1, column); row < 11 && south != nil && card.Connectors[2] != south.Connectors[0] {
+				t.Errorf("card %d at (%d, %d) doesn't match card %d to the south", card.Id, row, column, south.Id)
+			}
+		}
+	}
+}
+
+func Test_WorldRegenerates(t *testing.T) {
+	order := []ChunkPos{{0, 0}, {0, 1}, {1, 1}, {2, 1}}
+
+	a, b := getTestWorld(11), getTestWorld(11)
+	for _, pos := range order {
+		a.Chunk(pos.X, pos.Y)
+		b.Chunk(pos.X, pos.Y)
+	}
+	for _, pos := range order {
+		ca, cb := a.Chunk(pos.X, pos.Y), b.Chunk(pos.X, pos.Y)
+		if ca.Game.Seed != cb.Game.Seed {
+			t.Errorf("chunk %v seeds differ %d and %d", pos, ca.Game.Seed, cb.Game.Seed)
+		}
+		for i, row := range ca.Game.Board {
+			for j, tile := range row {
+				other := cb.Game.Board[i][j].Card
+				if (tile.Card == nil) != (other == nil) || (tile.Card != nil && tile.Card.Id != other.Id) {
+					t.Fatalf("chunk %v differs at (%d, %d)", pos, i, j)
+				}
+			}
+		}
+	}
+}
+
+func Test_chunkSeed(t *testing.T) {
+	seen := map[uint64]ChunkPos{}
+	for x := -2; x <= 2; x++ {
+		for y := -2; y <= 2; y++ {
+			seed := chunkSeed(42, x, y, 0)
+			if pos, ok := seen[seed]; ok {
+				t.Errorf("chunks %v and %v have the same seed", pos, ChunkPos{x, y})
+			}
+			seen[seed] = ChunkPos{x, y}
+		}
+	}
+	if chunkSeed(42, 1, 2, 0) != chunkSeed(42, 1, 2, 0) {
+		t.Errorf("chunk seed isn't deterministic")
+	}
+}
+
+func Test_floorDiv(t *testing.T) {
+	tests := []struct{ a, b, want int }{
+		{0, 8, 0}, {7, 8, 0}, {8, 8, 1}, {-1, 8, -1}, {-8, 8, -1}, {-9, 8, -2},
+	}
+	for _, tt := range tests {
+		if got := floorDiv(tt.a, tt.b); got != tt.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func Test_WorldFixedBorders(t *testing.T) {
+	a, b := getTestWorld(5), getTestWorld(5)
+	a.FixedBorders, b.FixedBorders = true, true
+
+	// the chunks are generated in opposite orders, so b has different neighbours when each chunk is made
+	order := []ChunkPos{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {-1, 0}, {0, -1}}
+	for n := range order {
+		a.Chunk(order[n].X, order[n].Y)
+		pos := order[len(order)-1-n]
+		b.Chunk(pos.X, pos.Y)
+	}
+
+	for _, pos := range order {
+		ca, cb := a.Chunk(pos.X, pos.Y), b.Chunk(pos.X, pos.Y)
+		for i, row := range ca.Game.Board {
+			for j, tile := range row {
+				other := cb.Game.Board[i][j].Card
+				if (tile.Card == nil) != (other == nil) || (tile.Card != nil && tile.Card.Id != other.Id) {
+					t.Fatalf("chunk %v differs at (%d, %d) depending on the order it was generated in", pos, i, j)
+				}
+			}
+		}
+	}
+
+	// chunks that share an edge agree on it
+	for row := -6; row < 12; row++ {
+		for column := -8; column < 16; column++ {
+			if !a.Generated(floorDiv(column, 8), floorDiv(row, 6)) {
+				continue
+			}
+			card := a.Card(row, column)
+			if card == nil {
+				continue
+			}
+			if a.Generated(floorDiv(column+1, 8), floorDiv(row, 6)) {
+				if east := a.Card(row, column+1); east != nil && card.Connectors[1] != east.Connectors[3] {
+					t.Errorf("card %d at (%d, %d) doesn't match card %d to the east", card.Id, row, column, east.Id)
+				}
+			}
+			if a.Generated(floorDiv(column, 8), floorDiv(row+1, 6)) {
+				if south := a.Card(row+1, column); south != nil && card.Connectors[2] != south.Connectors[0] {
+					t.Errorf("card %d at (%d, %d) doesn't match card %d to the south", card.Id, row, column, south.Id)
+				}
+			}
+		}
+	}
+}
+
+func Test_fixedEdgesShared(t *testing.T) {
+	w := getTestWorld(9)
+	w.FixedBorders = true
+
+	edges := w.fixedEdges(ChunkPos{2, 3})
+	east := w.fixedEdges(ChunkPos{3, 3})
+	south := w.fixedEdges(ChunkPos{2, 4})
+
+	compareConnectors(t, edges[1], east[3])
+	compareConnectors(t, edges[2], south[0])
+	if len(edges[0]) != 8 || len(edges[1]) != 6 {
+		t.Errorf("edge lengths got %d and %d, want 8 and 6", len(edges[0]), len(edges[1]))
+	}
+}
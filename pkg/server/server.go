@@ -0,0 +1,280 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io/fs"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"wfc2/pkg/game"
+)
+
+// RulesDir is where the named rules files are looked up in the filesystem
+const RulesDir = "static/rules"
+
+// MaxBoardSize limits the width and height of a requested board
+// each card placed looks at every cell again, so the time taken grows with the square of the cells
+const MaxBoardSize = 32
+
+// DefaultTimeout is how long a board is given to generate before the request fails
+const DefaultTimeout = 5 * time.Second
+
+const maxRequestBytes = 1 << 20
+
+// GenerateRequest is the body of a POST to /generate
+// either Rules or RulesName must be given, Width and Height override the board size in the rules
+// Width is the number of columns, the rules' BoardHeight, and Height the number of rows, the rules' BoardWidth
+type GenerateRequest struct {
+	Rules     *game.BasicRules `json:"rules"`
+	RulesName string           `json:"rulesName"`
+	Seed      *uint64          `json:"seed"`
+	Width     int              `json:"width"`
+	Height    int              `json:"height"`
+	Format    string           `json:"format"`
+}
+
+// GenerateResponse is the json returned by /generate
+// Tiles holds the card id of each cell, indexed the same way as Game.Board, with 0 for an empty cell
+// so there are Height rows of Width tiles
+type GenerateResponse struct {
+	Seed     uint64     `json:"seed"`
+	Width    int        `json:"width"`
+	Height   int        `json:"height"`
+	Complete bool       `json:"complete"`
+	Tiles    [][]int    `json:"tiles"`
+	Cards    []CardInfo `json:"cards"`
+}
+
+// CardInfo describes a card so that the ids in the tiles can be interpreted
+type CardInfo struct {
+	Id         int    `json:"id"`
+	Base       int    `json:"base"`
+	Rotation   int    `json:"rotation"`
+	Connectors string `json:"connectors"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server generates maps on demand from the rules and images in its filesystem
+type Server struct {
+	fs fs.FS
+
+	// cards for the named rules are only built once, as loading the images is the slow part
+	mu    sync.Mutex
+	cards map[string]map[int]*game.Card
+
+	// Timeout limits how long generating a board can take
+	Timeout time.Duration
+}
+
+func New(fsys fs.FS) *Server {
+	return &Server{
+		fs:      fsys,
+		cards:   make(map[string]map[int]*game.Card),
+		Timeout: DefaultTimeout,
+	}
+}
+
+// Handler returns the http handler serving the api
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rules", s.handleRules)
+	mux.HandleFunc("POST /generate", s.handleGenerate)
+	return mux
+}
+
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	names, err := game.ListRules(s.fs, RulesDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"rules": names})
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request is over %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	format := req.Format
+	if q := r.URL.Query().Get("format"); q != "" {
+		format = q
+	}
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "png" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, use json or png", format))
+		return
+	}
+
+	rules, cards, status, err := s.rules(req)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	seed := rand.Uint64()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.Timeout)
+	defer cancel()
+	g := game.NewGameWithRules(s.fs, rules, cards, seed)
+	complete, err := g.GenerateContext(ctx)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("board didn't generate in time: %w", err))
+		return
+	}
+
+	if format == "png" {
+		// encoded before the headers are written so a failure can still be reported
+		var buf bytes.Buffer
+		err = png.Encode(&buf, g.RenderImage())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("encoding png: %w", err))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("X-Wfc-Seed", fmt.Sprintf("%d", seed))
+		w.Header().Set("X-Wfc-Complete", fmt.Sprintf("%t", complete))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newGenerateResponse(g, complete))
+}
+
+// rules returns the validated rules and cards for the request
+// along with the status code to use if they can't be loaded
+func (s *Server) rules(req GenerateRequest) (game.BasicRules, map[int]*game.Card, int, error) {
+	var rules game.BasicRules
+	switch {
+	case req.Rules != nil && req.RulesName != "":
+		return rules, nil, http.StatusBadRequest, errors.New("give either rules or rulesName, not both")
+	case req.Rules != nil:
+		rules = *req.Rules
+	case req.RulesName != "":
+		if strings.ContainsAny(req.RulesName, `/\`) || !fs.ValidPath(req.RulesName) {
+			return rules, nil, http.StatusBadRequest, fmt.Errorf("invalid rules name %q", req.RulesName)
+		}
+		data, err := fs.ReadFile(s.fs, path.Join(RulesDir, req.RulesName+".json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return rules, nil, http.StatusNotFound, fmt.Errorf("unknown rules %q", req.RulesName)
+		}
+		if err != nil {
+			return rules, nil, http.StatusInternalServerError, err
+		}
+		rules, err = game.ParseRules(data)
+		if err != nil {
+			return rules, nil, http.StatusUnprocessableEntity, fmt.Errorf("rules %q: %w", req.RulesName, err)
+		}
+	default:
+		return rules, nil, http.StatusBadRequest, errors.New("rules or rulesName is required")
+	}
+
+	if req.Width < 0 || req.Height < 0 {
+		return rules, nil, http.StatusBadRequest, errors.New("width and height can't be negative")
+	}
+	if req.Width > 0 {
+		rules.BoardHeight = req.Width
+	}
+	if req.Height > 0 {
+		rules.BoardWidth = req.Height
+	}
+	if rules.BoardWidth > MaxBoardSize || rules.BoardHeight > MaxBoardSize {
+		return rules, nil, http.StatusBadRequest, fmt.Errorf("board can be at most %dx%d", MaxBoardSize, MaxBoardSize)
+	}
+
+	err := rules.Validate(s.fs)
+	if err != nil {
+		return rules, nil, http.StatusUnprocessableEntity, err
+	}
+	if rules.Topology == game.Voxel {
+		return rules, nil, http.StatusUnprocessableEntity, errors.New("voxel rules can't be generated as a map")
+	}
+
+	if req.Rules != nil {
+		cards, err := game.LoadCardsHeadless(rules, s.fs)
+		if err != nil {
+			return rules, nil, http.StatusUnprocessableEntity, err
+		}
+		return rules, cards, http.StatusOK, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cards, ok := s.cards[req.RulesName]
+	if !ok {
+		cards, err = game.LoadCardsHeadless(rules, s.fs)
+		if err != nil {
+			return rules, nil, http.StatusUnprocessableEntity, err
+		}
+		s.cards[req.RulesName] = cards
+	}
+	return rules, cards, http.StatusOK, nil
+}
+
+func newGenerateResponse(g *game.Game, complete bool) GenerateResponse {
+	resp := GenerateResponse{
+		Seed:     g.Seed,
+		Width:    g.Rules.BoardHeight,
+		Height:   g.Rules.BoardWidth,
+		Complete: complete,
+		Tiles:    make([][]int, len(g.Board)),
+	}
+
+	for i, row := range g.Board {
+		resp.Tiles[i] = make([]int, len(row))
+		for j, tile := range row {
+			if tile.Card != nil {
+				resp.Tiles[i][j] = tile.Card.Id
+			}
+		}
+	}
+
+	for id := 1; id <= len(g.Cards); id++ {
+		card := g.Cards[id]
+		resp.Cards = append(resp.Cards, CardInfo{
+			Id:         card.Id,
+			Base:       card.Base,
+			Rotation:   card.Rotation,
+			Connectors: card.ConnectorString(),
+		})
+	}
+
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_rules(t *testing.T) {
+	s := New(getFS())
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rules", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var got map[string][]string
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if len(got["rules"]) != 2 || got["rules"][0] != "basicRules" || got["rules"][1] != "tiny" {
+		t.Errorf("got %v, want basicRules and tiny", got)
+	}
+}
+
+func Test_generate(t *testing.T) {
+	s := New(getFS())
+
+	t.Run("named rules as json", func(t *testing.T) {
+		rec := post(s, "/generate", `{"rulesName": "basicRules", "seed": 42, "width": 5, "height": 4}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+
+		var got GenerateResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatalf("response is not json: %v", err)
+		}
+		if got.Seed != 42 || got.Width != 5 || got.Height != 4 || len(got.Tiles) != 4 || len(got.Tiles[0]) != 5 {
+			t.Errorf("board not the requested size: %+v", got)
+		}
+		if len(got.Cards) != 12 || got.Cards[2].Connectors != "GRGR" || got.Cards[2].Rotation != 90 {
+			t.Errorf("cards not described correctly: %+v", got.Cards)
+		}
+
+		// the same seed should give the same board
+		again := post(s, "/generate", `{"rulesName": "basicRules", "seed": 42, "width": 5, "height": 4}`)
+		if again.Body.String() != rec.Body.String() {
+			t.Errorf("same seed gave a different board")
+		}
+	})
+
+	t.Run("inline rules as png", func(t *testing.T) {
+		rec := post(s, "/generate?format=png", `{"width": 3, "height": 2, "rules": {"imageSize": 8, "boardWidth": 5, "boardHeight": 5,
+			"baseCards": [{"connectors": "GGGG", "chance": 1}]}}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		if rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("X-Wfc-Complete") != "true" {
+			t.Errorf("unexpected headers %v", rec.Header())
+		}
+
+		img, err := png.Decode(rec.Body)
+		if err != nil {
+			t.Fatalf("response is not a png: %v", err)
+		}
+		if img.Bounds().Dx() != 24 || img.Bounds().Dy() != 16 {
+			t.Errorf("got image size %v, want 24x16", img.Bounds())
+		}
+	})
+
+	errorTests := []struct {
+		name   string
+		body   string
+		status int
+		reason string
+	}{
+		{"not json", `{`, http.StatusBadRequest, "invalid request"},
+		{"no rules", `{"seed": 1}`, http.StatusBadRequest, "required"},
+		{"unknown rules", `{"rulesName": "nope"}`, http.StatusNotFound, "unknown rules"},
+		{"path in rules name", `{"rulesName": "../rules"}`, http.StatusBadRequest, "invalid rules name"},
+		{"too big", `{"rulesName": "basicRules", "width": 1000}`, http.StatusBadRequest, "at most"},
+		{"just too big", `{"rulesName": "basicRules", "height": 33}`, http.StatusBadRequest, "at most 32x32"},
+		{"big rules", `{"rules": {"imageSize": 8, "boardWidth": 100, "boardHeight": 3,
+			"baseCards": [{"connectors": "GGGG", "chance": 1}]}}`, http.StatusBadRequest, "at most"},
+		{"bad format", `{"rulesName": "basicRules", "format": "gif"}`, http.StatusBadRequest, "unknown format"},
+		{"invalid connectors", `{"rules": {"imageSize": 8, "boardWidth": 3, "boardHeight": 3,
+			"baseCards": [{"connectors": "GGXG"}]}}`, http.StatusUnprocessableEntity, "connectors"},
+		{"missing image", `{"rules": {"imageSize": 8, "boardWidth": 3, "boardHeight": 3,
+			"baseCards": [{"filename": "missing.png", "connectors": "GGGG"}]}}`, http.StatusUnprocessableEntity, "missing.png"},
+		{"seed tile off board", `{"rulesName": "tiny", "width": 1}`, http.StatusUnprocessableEntity, "off the board"},
+		{"body too large", `{"rulesName": "` + strings.Repeat("a", maxRequestBytes) + `"}`, http.StatusRequestEntityTooLarge, "bytes"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(s, "/generate", tt.body)
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.reason) {
+				t.Errorf("error %s does not mention %q", rec.Body, tt.reason)
+			}
+		})
+	}
+}
+
+func Test_generateTimeout(t *testing.T) {
+	s := New(getFS())
+	s.Timeout = 0
+
+	rec := post(s, "/generate", `{"rulesName": "basicRules", "seed": 42}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d: %s", rec.Code, http.StatusServiceUnavailable, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "in time") {
+		t.Errorf("error %s does not mention the timeout", rec.Body)
+	}
+}
+
+func post(s *Server, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body)))
+	return rec
+}
+
+func getFS() fstest.MapFS {
+	return fstest.MapFS{
+		"static/rules/basicRules.json": {
+			Data: []byte(`
+				{
+					"imageSize": 32,
+					"boardWidth": 20,
+					"boardHeight": 10,
+					"baseCards": [
+						{"filename":"", "imageLocation":[0,0,32,32], "connectors":"GGGG", "rotations": [], "chance": 10},
+						{"filename":"", "imageLocation":[0,0,32,32], "connectors":"RGRG", "rotations": [90], "chance": 10},
+						{"filename":"", "imageLocation":[0,0,32,32], "connectors":"RRRR", "rotations": [], "chance": 10},
+						{"filename":"", "imageLocation":[0,0,32,32], "connectors":"RRGG", "rotations": [90, 180, 270], "chance": 10},
+						{"filename":"", "imageLocation":[0,0,32,32], "connectors":"GGGR", "rotations": [90, 180, 270], "chance": 10}
+					],
+					"randomiser": 1
+				}`)},
+		"static/rules/tiny.json": {
+			Data: []byte(`
+				{
+					"imageSize": 8,
+					"boardWidth": 2,
+					"boardHeight": 2,
+					"baseCards": [
+						{"filename":"", "connectors":"GGGG", "chance": 1}
+					],
+					"seedTiles": [
+						{"x": 1, "y": 1, "id": 1}
+					]
+				}`)},
+	}
+}